@@ -5,11 +5,19 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"tera/deployment/internal/adapters/argocd"
+	"tera/deployment/internal/adapters/audit"
+	"tera/deployment/internal/adapters/helm"
 	"tera/deployment/internal/adapters/kafka"
+	"tera/deployment/internal/adapters/leader"
+	"tera/deployment/internal/adapters/rest"
+	"tera/deployment/internal/adapters/rpc"
+	"tera/deployment/internal/adapters/storage"
 	"tera/deployment/internal/domain/services"
+	"tera/deployment/internal/ports"
 	"tera/deployment/internal/usecases"
 	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/tracing"
 )
 
 func NewApp(conf *config.Config) *fx.App {
@@ -18,15 +26,66 @@ func NewApp(conf *config.Config) *fx.App {
 			func() *config.Config { return conf },
 			func() chan any { return make(chan any, 256) },
 			logger.Init,
+			tracing.Init,
 
 			// adapters
 			argocd.NewArgocd,
+			audit.NewFileAuditLog,
+			helm.NewChartRepository,
 			kafka.NewKafkaConsumer,
 			kafka.NewKafkaProducer,
+			leader.NewLeaderElector,
+			rest.NewServer,
+			rpc.NewServer,
+			storage.NewBoltStorage,
 
 			// services
+			services.NewAudit,
+			services.NewAuthenticator,
+			services.NewAuthorizer,
+			services.NewAdmissionController,
+			services.NewApprovals,
+			services.NewEventBroker,
+			services.NewFreeze,
+			services.NewHealthChecker,
+			services.NewHistory,
+			services.NewJobTracker,
+			services.NewOperationLock,
+			services.NewValuesValidator,
 			services.NewDeploymentManager,
 			services.NewEventProcessor,
+			services.NewReconciler,
+			services.NewScheduler,
+			fx.Annotate(
+				services.NewLeadership,
+				fx.ParamTags(``, `group:"leader_duties"`),
+			),
+
+			// leader duties
+			fx.Annotate(
+				func(reconciler usecases.Reconciler) usecases.LeaderDuty { return reconciler },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
+			fx.Annotate(
+				func(jobs usecases.JobTracker) usecases.LeaderDuty { return jobs },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
+			fx.Annotate(
+				func(approvals usecases.Approvals) usecases.LeaderDuty { return approvals },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
+			fx.Annotate(
+				func(freeze usecases.Freeze) usecases.LeaderDuty { return freeze },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
+			fx.Annotate(
+				func(scheduler usecases.Scheduler) usecases.LeaderDuty { return scheduler },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
+			fx.Annotate(
+				func(processor usecases.EventProcessor) usecases.LeaderDuty { return processor.Intake() },
+				fx.ResultTags(`group:"leader_duties"`),
+			),
 		),
 		fx.Invoke(
 			registerHooks,
@@ -38,6 +97,13 @@ func registerHooks(
 	lc fx.Lifecycle,
 	log *zap.Logger,
 	processor usecases.EventProcessor,
+	leadership usecases.Leadership,
+	health usecases.HealthChecker,
+	server ports.HTTPServer,
+	rpcServer ports.GRPCServer,
+	store ports.Storage,
+	auditLog ports.AuditLog,
+	provider *tracing.Provider,
 ) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -47,13 +113,57 @@ func registerHooks(
 				return err
 			}
 
+			if err := leadership.Start(); err != nil {
+				return err
+			}
+
+			if err := health.Start(); err != nil {
+				return err
+			}
+
+			if err := server.Start(); err != nil {
+				return err
+			}
+
+			if err := rpcServer.Start(); err != nil {
+				return err
+			}
+
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			if err := rpcServer.Close(); err != nil {
+				return err
+			}
+
+			if err := server.Close(); err != nil {
+				return err
+			}
+
 			if err := processor.Close(); err != nil {
 				return err
 			}
 
+			if err := health.Close(); err != nil {
+				return err
+			}
+
+			if err := leadership.Close(); err != nil {
+				return err
+			}
+
+			if err := store.Close(); err != nil {
+				return err
+			}
+
+			if err := auditLog.Close(); err != nil {
+				return err
+			}
+
+			if err := provider.Shutdown(ctx); err != nil {
+				return err
+			}
+
 			return nil
 		},
 	})
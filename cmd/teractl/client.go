@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"tera/deployment/internal/adapters/rest"
+	"time"
+)
+
+type Client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+type Event struct {
+	Key   string
+	Value map[string]any
+}
+
+// Accepted is returned for a deployment the server queued instead of starting
+// it, either until it is approved or until a freeze window closes.
+type Accepted struct {
+	Approval *rest.ApprovalResponse
+	Deferral *rest.DeferralResponse
+}
+
+func (accepted *Accepted) Error() string {
+	if accepted.Approval != nil {
+		return fmt.Sprintf(
+			"%s of '%s' awaits approval '%s'",
+			accepted.Approval.Action,
+			accepted.Approval.Instance,
+			accepted.Approval.ID,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s of '%s' is deferred as '%s' until %s",
+		accepted.Deferral.Action,
+		accepted.Deferral.Instance,
+		accepted.Deferral.ID,
+		accepted.Deferral.ReleaseAt.Format(time.RFC3339),
+	)
+}
+
+func NewClient(server, token string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimSuffix(server, "/"),
+		token:   token,
+	}
+}
+
+func (ctx *Client) List() ([]rest.ApplicationResponse, error) {
+	var response []rest.ApplicationResponse
+
+	return response, ctx.do(http.MethodGet, "/applications", nil, &response)
+}
+
+func (ctx *Client) Get(instance string) (*rest.ApplicationResponse, error) {
+	var response rest.ApplicationResponse
+
+	return &response, ctx.do(http.MethodGet, "/applications/"+url.PathEscape(instance), nil, &response)
+}
+
+func (ctx *Client) Create(request rest.CreateApplicationRequest) (*rest.ApplicationResponse, error) {
+	var response rest.ApplicationResponse
+
+	return &response, ctx.do(http.MethodPost, "/applications", request, &response)
+}
+
+func (ctx *Client) Upgrade(instance string, request rest.UpgradeApplicationRequest) (*rest.ApplicationResponse, error) {
+	var response rest.ApplicationResponse
+
+	return &response, ctx.do(http.MethodPut, "/applications/"+url.PathEscape(instance), request, &response)
+}
+
+func (ctx *Client) Rollback(instance string, request rest.RollbackApplicationRequest) (*rest.ApplicationResponse, error) {
+	var response rest.ApplicationResponse
+
+	return &response, ctx.do(http.MethodPost, "/applications/"+url.PathEscape(instance)+"/rollback", request, &response)
+}
+
+func (ctx *Client) Delete(instance string, override bool) error {
+	path := "/applications/" + url.PathEscape(instance)
+	if override {
+		path += "?override=true"
+	}
+
+	return ctx.do(http.MethodDelete, path, nil, nil)
+}
+
+func (ctx *Client) Graph() (*rest.GraphResponse, error) {
+	var response rest.GraphResponse
+
+	return &response, ctx.do(http.MethodGet, "/graph", nil, &response)
+}
+
+func (ctx *Client) Job(id string) (*rest.JobResponse, error) {
+	var response rest.JobResponse
+
+	return &response, ctx.do(http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &response)
+}
+
+func (ctx *Client) Events(background context.Context, query url.Values, handle func(event Event) bool) error {
+	request, err := http.NewRequestWithContext(background, http.MethodGet, ctx.baseURL+"/events?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	ctx.identify(request)
+
+	response, err := (&http.Client{}).Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return readError(response)
+	}
+
+	var event Event
+
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event.Key = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Value); err != nil {
+				return err
+			}
+		case line == "" && event.Key != "":
+			if !handle(event) {
+				return nil
+			}
+
+			event = Event{}
+		}
+	}
+
+	if background.Err() != nil {
+		return nil
+	}
+
+	return scanner.Err()
+}
+
+func (ctx *Client) do(method, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, ctx.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	ctx.identify(request)
+
+	response, err := ctx.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return readError(response)
+	}
+	if response.StatusCode == http.StatusAccepted {
+		return readAccepted(response)
+	}
+
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// identify sends the bearer token when there is one; servers without tokens
+// take the X-Tera-User header instead.
+func (ctx *Client) identify(request *http.Request) {
+	if ctx.token != "" {
+		request.Header.Set("Authorization", "Bearer "+ctx.token)
+	}
+	if user := os.Getenv("USER"); user != "" {
+		request.Header.Set("X-Tera-User", user)
+	}
+}
+
+// readAccepted tells the deferral of a freeze window, which names the window,
+// from the approval a protected namespace asks for.
+func readAccepted(response *http.Response) error {
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	var probe struct {
+		Window string `json:"window"`
+	}
+	if err = json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+
+	if probe.Window != "" {
+		var deferral rest.DeferralResponse
+		if err = json.Unmarshal(data, &deferral); err != nil {
+			return err
+		}
+
+		return &Accepted{Deferral: &deferral}
+	}
+
+	var approval rest.ApprovalResponse
+	if err = json.Unmarshal(data, &approval); err != nil {
+		return err
+	}
+
+	return &Accepted{Approval: &approval}
+}
+
+func readError(response *http.Response) error {
+	var body rest.ErrorResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("server responded with status %d", response.StatusCode)
+	}
+
+	message := body.Error
+	for _, violation := range body.Violations {
+		message += fmt.Sprintf("\n  %s: %s", violation.Path, violation.Message)
+	}
+
+	return fmt.Errorf("%s (status %d)", message, response.StatusCode)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"tera/deployment/internal/adapters/rest"
+	"time"
+)
+
+type waitOptions struct {
+	wait    bool
+	timeout time.Duration
+}
+
+func (opts *waitOptions) register(command *cobra.Command) {
+	command.Flags().BoolVar(&opts.wait, "wait", false, "block until the deployment job finishes")
+	command.Flags().DurationVar(&opts.timeout, "timeout", 5*time.Minute, "maximum time to wait")
+}
+
+func listCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List deployed applications",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			applications, err := NewClient(opts.server, opts.token).List()
+			if err != nil {
+				return err
+			}
+
+			return render(command.OutOrStdout(), opts.output, applications, func() table {
+				return applicationTable(applications...)
+			})
+		},
+	}
+}
+
+func statusCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <instance>",
+		Short: "Show the status of an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			application, err := NewClient(opts.server, opts.token).Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return render(command.OutOrStdout(), opts.output, application, func() table {
+				return applicationTable(*application)
+			})
+		},
+	}
+}
+
+func deployCommand(opts *options) *cobra.Command {
+	var (
+		request rest.CreateApplicationRequest
+		values  []string
+		wait    waitOptions
+	)
+
+	command := &cobra.Command{
+		Use:   "deploy <service>",
+		Short: "Deploy a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			parsed, err := parseValues(values)
+			if err != nil {
+				return err
+			}
+
+			request.Service = args[0]
+			request.Values = parsed
+			request.Requester = lo.CoalesceOrEmpty(request.Requester, os.Getenv("USER"))
+
+			client := NewClient(opts.server, opts.token)
+
+			application, err := client.Create(request)
+			if err != nil {
+				return queued(command, opts, err, wait)
+			}
+
+			return finish(command, opts, client, application, wait)
+		},
+	}
+	command.Flags().StringVar(&request.Version, "version", "", "chart version")
+	command.Flags().StringVar(&request.Namespace, "namespace", "", "target namespace")
+	command.Flags().StringVar(&request.Cluster, "cluster", "", "target cluster")
+	command.Flags().StringVar(&request.Instance, "instance", "", "instance name")
+	command.Flags().StringVar(&request.Requester, "requester", "", "requester identity")
+	command.Flags().StringArrayVar(&values, "set", nil, "helm value (key=value)")
+	command.Flags().BoolVar(&request.Override, "override", false, "emergency override of an active freeze window")
+	wait.register(command)
+
+	return command
+}
+
+func upgradeCommand(opts *options) *cobra.Command {
+	var (
+		request rest.UpgradeApplicationRequest
+		values  []string
+		wait    waitOptions
+	)
+
+	command := &cobra.Command{
+		Use:   "upgrade <instance>",
+		Short: "Upgrade an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			parsed, err := parseValues(values)
+			if err != nil {
+				return err
+			}
+
+			request.Values = parsed
+			request.Requester = lo.CoalesceOrEmpty(request.Requester, os.Getenv("USER"))
+
+			client := NewClient(opts.server, opts.token)
+
+			application, err := client.Upgrade(args[0], request)
+			if err != nil {
+				return queued(command, opts, err, wait)
+			}
+
+			return finish(command, opts, client, application, wait)
+		},
+	}
+	command.Flags().StringVar(&request.Version, "version", "", "chart version")
+	command.Flags().StringVar(&request.Requester, "requester", "", "requester identity")
+	command.Flags().StringArrayVar(&values, "set", nil, "helm value (key=value)")
+	command.Flags().BoolVar(&request.Replace, "replace", false, "replace all helm values instead of merging with the current ones")
+	command.Flags().BoolVar(&request.Override, "override", false, "emergency override of an active freeze window")
+	wait.register(command)
+
+	return command
+}
+
+func rollbackCommand(opts *options) *cobra.Command {
+	var (
+		request rest.RollbackApplicationRequest
+		wait    waitOptions
+	)
+
+	command := &cobra.Command{
+		Use:   "rollback <instance>",
+		Short: "Roll an application back to a previous revision",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			request.Requester = lo.CoalesceOrEmpty(request.Requester, os.Getenv("USER"))
+
+			client := NewClient(opts.server, opts.token)
+
+			application, err := client.Rollback(args[0], request)
+			if err != nil {
+				return queued(command, opts, err, wait)
+			}
+
+			return finish(command, opts, client, application, wait)
+		},
+	}
+	command.Flags().Int64Var(&request.Revision, "revision", 0, "revision id (defaults to the previous revision)")
+	command.Flags().StringVar(&request.Requester, "requester", "", "requester identity")
+	command.Flags().BoolVar(&request.Override, "override", false, "emergency override of an active freeze window")
+	wait.register(command)
+
+	return command
+}
+
+func deleteCommand(opts *options) *cobra.Command {
+	var override bool
+
+	command := &cobra.Command{
+		Use:   "delete <instance>",
+		Short: "Delete an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			if err := NewClient(opts.server, opts.token).Delete(args[0], override); err != nil {
+				return queued(command, opts, err, waitOptions{})
+			}
+
+			_, _ = fmt.Fprintf(command.OutOrStdout(), "application '%s' deleted\n", args[0])
+
+			return nil
+		},
+	}
+	command.Flags().BoolVar(&override, "override", false, "emergency override of an active freeze window")
+
+	return command
+}
+
+func graphCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "graph",
+		Short: "Show the service dependency graph",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			graph, err := NewClient(opts.server, opts.token).Graph()
+			if err != nil {
+				return err
+			}
+
+			return render(command.OutOrStdout(), opts.output, graph, func() table {
+				return table{
+					headers: []string{"SERVICE", "VERSION", "DEPENDS", "INSTANCES"},
+					rows: lo.Map(graph.Nodes, func(node rest.GraphNodeResponse, _ int) []string {
+						return []string{
+							node.Service,
+							node.Version,
+							strings.Join(lo.Map(node.Depends, func(item rest.DependencyResponse, _ int) string {
+								return strings.TrimSuffix(item.Service+"@"+item.Version, "@")
+							}), ", "),
+							strings.Join(lo.Map(node.Instances, func(item rest.ApplicationResponse, _ int) string {
+								return item.Instance + "/" + item.Cluster
+							}), ", "),
+						}
+					}),
+				}
+			})
+		},
+	}
+}
+
+func jobCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "job <id>",
+		Short: "Show the state of a deployment job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			job, err := NewClient(opts.server, opts.token).Job(args[0])
+			if err != nil {
+				return err
+			}
+
+			return render(command.OutOrStdout(), opts.output, job, func() table {
+				return table{
+					headers: []string{"ID", "ACTION", "INSTANCE", "VERSION", "STATE", "MESSAGE"},
+					rows:    [][]string{{job.ID, job.Action, job.Instance, job.Version, job.State, job.Message}},
+				}
+			})
+		},
+	}
+}
+
+func logsCommand(opts *options) *cobra.Command {
+	var eventTypes []string
+
+	command := &cobra.Command{
+		Use:   "logs [instance]",
+		Short: "Follow deployment events",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			background, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			query := url.Values{}
+			for _, item := range eventTypes {
+				query.Add("type", item)
+			}
+
+			return NewClient(opts.server, opts.token).Events(background, query, func(event Event) bool {
+				if len(args) > 0 && event.Value["instance"] != args[0] {
+					return true
+				}
+
+				printEvent(command, opts, event)
+
+				return true
+			})
+		},
+	}
+	command.Flags().StringSliceVar(&eventTypes, "type", nil, "event types to show")
+
+	return command
+}
+
+func finish(
+	command *cobra.Command,
+	opts *options,
+	client *Client,
+	application *rest.ApplicationResponse,
+	wait waitOptions,
+) error {
+	if wait.wait {
+		var err error
+		if application, err = waitForJob(command, client, application, wait.timeout); err != nil {
+			return err
+		}
+	}
+
+	return render(command.OutOrStdout(), opts.output, application, func() table {
+		return applicationTable(*application)
+	})
+}
+
+// queued prints the approval or deferral the server queued the deployment as;
+// until it runs there is no job to wait for.
+func queued(command *cobra.Command, opts *options, err error, wait waitOptions) error {
+	var accepted *Accepted
+	if !errors.As(err, &accepted) {
+		return err
+	}
+
+	if wait.wait {
+		_, _ = fmt.Fprintf(command.ErrOrStderr(), "not waiting: %s\n", accepted.Error())
+	}
+
+	if accepted.Approval != nil {
+		return render(command.OutOrStdout(), opts.output, accepted.Approval, func() table {
+			return queuedTable("awaiting approval", accepted.Approval.ID, accepted.Approval.Action, accepted.Approval.Instance, fmt.Sprintf(
+				"%d approval(s) required before %s",
+				accepted.Approval.Required,
+				accepted.Approval.ExpiresAt.Format(time.RFC3339),
+			))
+		})
+	}
+
+	return render(command.OutOrStdout(), opts.output, accepted.Deferral, func() table {
+		return queuedTable("deferred", accepted.Deferral.ID, accepted.Deferral.Action, accepted.Deferral.Instance, fmt.Sprintf(
+			"frozen by '%s' until %s",
+			accepted.Deferral.Window,
+			accepted.Deferral.ReleaseAt.Format(time.RFC3339),
+		))
+	})
+}
+
+func waitForJob(
+	command *cobra.Command,
+	client *Client,
+	application *rest.ApplicationResponse,
+	timeout time.Duration,
+) (*rest.ApplicationResponse, error) {
+	if application.Job == "" {
+		return nil, fmt.Errorf("'%s' has no deployment job to wait for", application.Instance)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	var last string
+	for {
+		job, err := client.Job(application.Job)
+		if err != nil {
+			return nil, err
+		}
+
+		if job.State != last {
+			last = job.State
+			_, _ = fmt.Fprintf(command.ErrOrStderr(), "%s: job %s %s\n", job.Instance, job.ID, job.State)
+		}
+
+		switch job.State {
+		case "succeeded":
+			return client.Get(job.Instance)
+		case "failed", "timed_out":
+			return nil, fmt.Errorf("job '%s' %s: %s", job.ID, job.State, job.Message)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for job '%s' of '%s'", job.ID, job.Instance)
+		}
+
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func printEvent(command *cobra.Command, opts *options, event Event) {
+	if opts.output == "json" || opts.output == "yaml" {
+		_ = render(command.OutOrStdout(), opts.output, event.Value, nil)
+		return
+	}
+
+	eventType, _ := event.Value["type"].(string)
+
+	line := fmt.Sprintf("%v %-22v %v", event.Value["time"], lo.CoalesceOrEmpty(eventType, event.Key), event.Value["instance"])
+	if status, ok := event.Value["status"].(map[string]any); ok {
+		line += fmt.Sprintf(" sync=%v health=%v", status["sync"], status["health"])
+	}
+	if message, ok := event.Value["message"].(string); ok && message != "" {
+		line += " " + message
+	}
+
+	_, _ = fmt.Fprintln(command.OutOrStdout(), line)
+}
+
+func applicationTable(applications ...rest.ApplicationResponse) table {
+	return table{
+		headers: []string{"INSTANCE", "SERVICE", "VERSION", "NAMESPACE", "CLUSTER", "SYNC", "HEALTH"},
+		rows: lo.Map(applications, func(item rest.ApplicationResponse, _ int) []string {
+			return []string{
+				item.Instance,
+				item.Service,
+				item.Version,
+				item.Namespace,
+				item.Cluster,
+				item.Status.Sync,
+				item.Status.Health,
+			}
+		}),
+	}
+}
+
+func queuedTable(state, id, action, instance, detail string) table {
+	return table{
+		headers: []string{"STATE", "ID", "ACTION", "INSTANCE", "DETAIL"},
+		rows:    [][]string{{state, id, action, instance, detail}},
+	}
+}
+
+func parseValues(values []string) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+
+	for _, item := range values {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid value '%s', expected key=value", item)
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
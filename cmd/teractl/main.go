@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type options struct {
+	server string
+	token  string
+	output string
+}
+
+func main() {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:   "teractl",
+		Short: "Command-line client for the Tera deployment server",
+		Long: "Command-line client for the Tera deployment server.\n\n" +
+			"With --token (or $TERACTL_TOKEN) requests carry a bearer token and the server acts as the\n" +
+			"user and team configured for it. Servers without tokens take the requester from --requester\n" +
+			"or $USER as sent in X-Tera-User, so their authorization rules are advisory.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVar(
+		&opts.server,
+		"server",
+		lo.CoalesceOrEmpty(os.Getenv("TERACTL_SERVER"), "http://localhost:8080"),
+		"deployment server address",
+	)
+	root.PersistentFlags().StringVar(&opts.token, "token", os.Getenv("TERACTL_TOKEN"), "bearer token of the requester")
+	root.PersistentFlags().StringVarP(&opts.output, "output", "o", "table", "output format (table, json, yaml)")
+
+	root.AddCommand(
+		listCommand(opts),
+		statusCommand(opts),
+		deployCommand(opts),
+		upgradeCommand(opts),
+		rollbackCommand(opts),
+		deleteCommand(opts),
+		graphCommand(opts),
+		jobCommand(opts),
+		logsCommand(opts),
+	)
+
+	if err := root.Execute(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func render(writer io.Writer, format string, value any, tableOf func() table) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case "yaml":
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		var document any
+		if err = yaml.Unmarshal(data, &document); err != nil {
+			return err
+		}
+
+		return yaml.NewEncoder(writer).Encode(document)
+	case "table", "":
+		result := tableOf()
+
+		tab := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(tab, strings.Join(result.headers, "\t"))
+		for _, row := range result.rows {
+			_, _ = fmt.Fprintln(tab, strings.Join(row, "\t"))
+		}
+
+		return tab.Flush()
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+}
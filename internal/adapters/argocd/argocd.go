@@ -2,30 +2,49 @@ package argocd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/project"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
 	"github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
-	"github.com/argoproj/gitops-engine/pkg/health"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"tera/deployment/internal/domain/models"
 	"tera/deployment/internal/ports"
 	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
-	"time"
 
 	"github.com/argoproj/argo-cd/v2/pkg/apiclient"
 )
 
+var (
+	invalidLabelCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+	identityAnnotationKeys = []string{
+		models.AnnotationRequestedBy,
+		models.AnnotationTeam,
+		models.AnnotationOrigin,
+		models.AnnotationJob,
+	}
+)
+
 type Argocd struct {
-	events        chan<- any
 	client        apiclient.Client
 	repository    string
+	project       string
 	metaNamespace string
+	clusters      map[string]string
 }
 
-func NewArgocd(conf *config.Config, events chan any) ports.Argocd {
+func NewArgocd(conf *config.Config) ports.Argocd {
 	client, err := apiclient.NewClient(&apiclient.ClientOptions{
 		ServerAddr: conf.Argocd.URL,
 		AuthToken:  conf.Argocd.Token,
@@ -37,15 +56,25 @@ func NewArgocd(conf *config.Config, events chan any) ports.Argocd {
 		panic(err)
 	}
 
-	return &Argocd{
-		events:        events,
-		client:        client,
-		repository:    conf.Argocd.Repository,
-		metaNamespace: conf.Argocd.Metadata.Namespace,
+	clusters := lo.SliceToMap(conf.Clusters, func(item config.ClusterConfig) (string, string) {
+		return item.Name, item.Server
+	})
+	if _, ok := clusters[models.DefaultCluster]; !ok {
+		clusters[models.DefaultCluster] = models.DefaultClusterServer
+	}
+
+	return &instrumented{
+		next: &Argocd{
+			client:        client,
+			repository:    conf.Argocd.Repository,
+			project:       lo.CoalesceOrEmpty(conf.Argocd.Project, "default"),
+			metaNamespace: conf.Argocd.Metadata.Namespace,
+			clusters:      clusters,
+		},
 	}
 }
 
-func (ctx *Argocd) GetList() ([]models.Application, error) {
+func (ctx *Argocd) GetList(background context.Context) ([]models.Application, error) {
 	io, client, err := ctx.client.NewApplicationClient()
 	if err != nil {
 		logger.Error("failed to create Argocd application client", zap.Error(err))
@@ -54,7 +83,9 @@ func (ctx *Argocd) GetList() ([]models.Application, error) {
 	}
 	defer io.Close()
 
-	data, err := client.List(context.Background(), &application.ApplicationQuery{})
+	data, err := client.List(background, &application.ApplicationQuery{
+		Selector: lo.ToPtr(fmt.Sprintf("%s=%s", models.LabelManagedBy, models.ManagedBy)),
+	})
 	if err != nil {
 		logger.Error("failed to list Argocd applications", zap.Error(err))
 
@@ -62,14 +93,18 @@ func (ctx *Argocd) GetList() ([]models.Application, error) {
 	}
 
 	return lo.Map(data.Items, func(item v1alpha1.Application, index int) models.Application {
-		return models.Application{
-			Name:    strings.ToLower(item.Name),
-			Version: item.Spec.Source.TargetRevision,
-		}
+		return ctx.toApplication(&item)
 	}), nil
 }
 
-func (ctx *Argocd) Create(service, version, namespace string, values map[string]string) (*models.Application, error) {
+func (ctx *Argocd) Create(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	server, ok := ctx.clusters[request.Cluster]
+	if !ok {
+		logger.Error("unknown cluster", zap.String("cluster", request.Cluster))
+
+		return nil, models.NewError(models.ErrClusterNotAllowed, "unknown cluster '%s'", request.Cluster)
+	}
+
 	io, client, err := ctx.client.NewApplicationClient()
 	if err != nil {
 		logger.Error("failed to create Argocd application client", zap.Error(err))
@@ -78,35 +113,29 @@ func (ctx *Argocd) Create(service, version, namespace string, values map[string]
 	}
 	defer io.Close()
 
-	parameters := lo.MapToSlice(values, func(key string, value string) v1alpha1.HelmParameter {
-		return v1alpha1.HelmParameter{
-			Name:        key,
-			Value:       value,
-			ForceString: false,
-		}
-	})
-
-	data, err := client.Create(context.Background(), &application.ApplicationCreateRequest{
+	data, err := client.Create(background, &application.ApplicationCreateRequest{
 		Application: &v1alpha1.Application{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      service,
-				Namespace: ctx.metaNamespace,
+				Name:        request.Instance,
+				Namespace:   ctx.metaNamespace,
+				Labels:      ownershipLabels(request),
+				Annotations: identityAnnotations(request),
 			},
 			Spec: v1alpha1.ApplicationSpec{
-				Project: "default",
+				Project: ctx.project,
 				Source: &v1alpha1.ApplicationSource{
 					RepoURL:        ctx.repository,
-					Chart:          service,
-					TargetRevision: version,
+					Chart:          request.Service,
+					TargetRevision: request.Version,
 					Helm: &v1alpha1.ApplicationSourceHelm{
-						ReleaseName: service,
-						Namespace:   namespace,
-						Parameters:  parameters,
+						ReleaseName: request.Instance,
+						Namespace:   request.Namespace,
+						Parameters:  helmParameters(request.Values),
 					},
 				},
 				Destination: v1alpha1.ApplicationDestination{
-					Server:    "https://kubernetes.default.svc",
-					Namespace: namespace,
+					Server:    server,
+					Namespace: request.Namespace,
 				},
 				SyncPolicy: &v1alpha1.SyncPolicy{
 					Automated: &v1alpha1.SyncPolicyAutomated{
@@ -136,28 +165,176 @@ func (ctx *Argocd) Create(service, version, namespace string, values map[string]
 	if err != nil {
 		logger.Error("failed to create Argocd application", zap.Error(err))
 
+		return nil, convertError(err)
+	}
+
+	return lo.ToPtr(ctx.toApplication(data)), nil
+}
+
+func (ctx *Argocd) Get(background context.Context, instance string) (*models.Application, error) {
+	io, client, err := ctx.client.NewApplicationClient()
+	if err != nil {
+		logger.Error("failed to create Argocd application client", zap.Error(err))
+
 		return nil, err
 	}
+	defer io.Close()
 
-	go func() {
-		if err = ctx.waitForApplicationSync(service, version, time.Minute*3); err != nil {
-			logger.Error("Argocd.Create: application sync failed", zap.Error(err))
-		}
-	}()
+	data, err := ctx.getManaged(background, client, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.ToPtr(ctx.toApplication(data)), nil
+}
+
+func (ctx *Argocd) Update(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	io, client, err := ctx.client.NewApplicationClient()
+	if err != nil {
+		logger.Error("failed to create Argocd application client", zap.Error(err))
+
+		return nil, err
+	}
+	defer io.Close()
+
+	data, err := ctx.getManaged(background, client, request.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Labels = lo.Assign(data.Labels, ownershipLabels(request))
+	data.Annotations = lo.Assign(
+		lo.OmitByKeys(data.Annotations, identityAnnotationKeys),
+		identityAnnotations(request),
+	)
+	data.Spec.Source.TargetRevision = request.Version
+	if data.Spec.Source.Helm == nil {
+		data.Spec.Source.Helm = &v1alpha1.ApplicationSourceHelm{}
+	}
+	data.Spec.Source.Helm.Parameters = lo.Ternary(
+		request.Replace,
+		helmParameters(request.Values),
+		mergeHelmParameters(data.Spec.Source.Helm.Parameters, request.Values),
+	)
+
+	data, err = client.Update(background, &application.ApplicationUpdateRequest{
+		Application: data,
+		Validate:    lo.ToPtr(true),
+	})
+	if err != nil {
+		logger.Error("failed to update Argocd application", zap.Error(err))
+
+		return nil, convertError(err)
+	}
+
+	return lo.ToPtr(ctx.toApplication(data)), nil
+}
+
+func (ctx *Argocd) Delete(background context.Context, instance string) error {
+	io, client, err := ctx.client.NewApplicationClient()
+	if err != nil {
+		logger.Error("failed to create Argocd application client", zap.Error(err))
+
+		return err
+	}
+	defer io.Close()
+
+	if _, err = ctx.getManaged(background, client, instance); err != nil {
+		return err
+	}
+
+	if _, err = client.Delete(background, &application.ApplicationDeleteRequest{
+		Name:         &instance,
+		AppNamespace: &ctx.metaNamespace,
+		Cascade:      lo.ToPtr(true),
+	}); err != nil {
+		logger.Error("failed to delete Argocd application", zap.Error(err))
+
+		return convertError(err)
+	}
+
+	return nil
+}
+
+func (ctx *Argocd) History(background context.Context, instance string) ([]models.Revision, error) {
+	io, client, err := ctx.client.NewApplicationClient()
+	if err != nil {
+		logger.Error("failed to create Argocd application client", zap.Error(err))
+
+		return nil, err
+	}
+	defer io.Close()
+
+	data, err := ctx.getManaged(background, client, instance)
+	if err != nil {
+		return nil, err
+	}
 
-	return &models.Application{
-		Name:    strings.ToLower(data.Name),
-		Version: data.Spec.Source.TargetRevision,
-	}, nil
+	return lo.Map(data.Status.History, func(item v1alpha1.RevisionHistory, _ int) models.Revision {
+		return models.Revision{
+			ID:         item.ID,
+			Version:    item.Source.TargetRevision,
+			Values:     helmValues(item.Source.Helm),
+			DeployedAt: item.DeployedAt.Time,
+		}
+	}), nil
 }
 
-func (ctx *Argocd) waitForApplicationSync(service, version string, timeout time.Duration) error {
-	syncCtx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+func (ctx *Argocd) Adopt(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	io, client, err := ctx.client.NewApplicationClient()
+	if err != nil {
+		logger.Error("failed to create Argocd application client", zap.Error(err))
+
+		return nil, err
+	}
+	defer io.Close()
+
+	data, err := client.Get(background, &application.ApplicationQuery{
+		Name:         &request.Instance,
+		AppNamespace: &ctx.metaNamespace,
+	})
+	if err != nil {
+		logger.Error("failed to get Argocd application", zap.Error(err))
+
+		return nil, convertError(err)
+	}
+
+	if data.Labels[models.LabelManagedBy] == models.ManagedBy {
+		return nil, models.NewError(models.ErrApplicationExists, "application '%s' is already managed", request.Instance)
+	}
+	if data.Spec.Source == nil || data.Spec.Source.Chart != request.Service {
+		return nil, models.NewError(models.ErrServiceMismatch, "application '%s' does not deploy chart '%s'", request.Instance, request.Service)
+	}
+
+	request.Namespace = data.Spec.Destination.Namespace
+	request.Version = data.Spec.Source.TargetRevision
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels":      ownershipLabels(request),
+			"annotations": identityAnnotations(request),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = client.Patch(background, &application.ApplicationPatchRequest{
+		Name:         &request.Instance,
+		AppNamespace: &ctx.metaNamespace,
+		Patch:        lo.ToPtr(string(patch)),
+		PatchType:    lo.ToPtr("merge"),
+	})
+	if err != nil {
+		logger.Error("failed to adopt Argocd application", zap.Error(err))
 
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+		return nil, convertError(err)
+	}
 
+	return lo.ToPtr(ctx.toApplication(data)), nil
+}
+
+func (ctx *Argocd) Sync(background context.Context, instance string) error {
 	io, client, err := ctx.client.NewApplicationClient()
 	if err != nil {
 		logger.Error("failed to create Argocd application client", zap.Error(err))
@@ -166,52 +343,243 @@ func (ctx *Argocd) waitForApplicationSync(service, version string, timeout time.
 	}
 	defer io.Close()
 
-	for {
-		select {
-		case <-syncCtx.Done():
-			return syncCtx.Err()
-		case <-ticker.C:
-			data, err := client.Get(syncCtx, &application.ApplicationQuery{
-				Name: &service,
+	if _, err = client.Sync(background, &application.ApplicationSyncRequest{
+		Name:         &instance,
+		AppNamespace: &ctx.metaNamespace,
+	}); err != nil {
+		logger.Error("failed to sync Argocd application", zap.String("instance", instance), zap.Error(err))
+
+		return convertError(err)
+	}
+
+	return nil
+}
+
+func (ctx *Argocd) SyncWindows(background context.Context, windows []models.SyncWindow) error {
+	io, client, err := ctx.client.NewProjectClient()
+	if err != nil {
+		logger.Error("failed to create Argocd project client", zap.Error(err))
+
+		return err
+	}
+	defer io.Close()
+
+	data, err := client.Get(background, &project.ProjectQuery{Name: ctx.project})
+	if err != nil {
+		logger.Error("failed to get Argocd project", zap.String("project", ctx.project), zap.Error(err))
+
+		return err
+	}
+
+	var previous []v1alpha1.SyncWindow
+	if value, ok := data.Annotations[models.AnnotationSyncWindows]; ok {
+		if err = json.Unmarshal([]byte(value), &previous); err != nil {
+			logger.Warn("ignoring unreadable sync window annotation", zap.String("project", ctx.project), zap.Error(err))
+		}
+	}
+
+	managed := lo.Map(windows, func(item models.SyncWindow, _ int) v1alpha1.SyncWindow {
+		return v1alpha1.SyncWindow{
+			Kind:         "deny",
+			Schedule:     item.Schedule,
+			Duration:     item.Duration.String(),
+			Applications: item.Applications,
+			Namespaces:   item.Namespaces,
+			Clusters:     ctx.clusterServers(item.Clusters),
+			ManualSync:   true,
+			TimeZone:     item.Timezone,
+		}
+	})
+
+	annotation, err := json.Marshal(managed)
+	if err != nil {
+		return err
+	}
+
+	data.Spec.SyncWindows = append(
+		lo.Reject(data.Spec.SyncWindows, func(item *v1alpha1.SyncWindow, _ int) bool {
+			return lo.ContainsBy(previous, func(window v1alpha1.SyncWindow) bool {
+				return sameSyncWindow(*item, window)
 			})
-			if err != nil {
-				logger.Error("failed to get Argocd application", zap.Error(err))
-
-				ctx.events <- &models.SystemMessage{
-					Key: models.ArgocdApplicationStatus,
-					Value: map[string]interface{}{
-						"service": service,
-						"version": version,
-						"message": "failed to get Argocd application",
-					},
-				}
-
-				continue
-			}
-
-			logger.Info(
-				"Argocd application status",
-				zap.Any("status", data.Status.Sync.Status),
-				zap.Any("healthStatus", data.Status.Health.Status),
-			)
-
-			ctx.events <- &models.SystemMessage{
-				Key: models.ArgocdApplicationStatus,
-				Value: map[string]interface{}{
-					"service": service,
-					"version": version,
-					"status": map[string]interface{}{
-						"sync":   data.Status.Sync.Status,
-						"health": data.Status.Health.Status,
-					},
-				},
-			}
+		}),
+		lo.ToSlicePtr(managed)...,
+	)
+	if data.Annotations == nil {
+		data.Annotations = make(map[string]string)
+	}
+	data.Annotations[models.AnnotationSyncWindows] = string(annotation)
+
+	if _, err = client.Update(background, &project.ProjectUpdateRequest{Project: data}); err != nil {
+		logger.Error("failed to update Argocd project sync windows", zap.String("project", ctx.project), zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (ctx *Argocd) Ping(background context.Context) error {
+	io, client, err := ctx.client.NewSessionClient()
+	if err != nil {
+		logger.Error("failed to create Argocd session client", zap.Error(err))
+
+		return err
+	}
+	defer io.Close()
+
+	info, err := client.GetUserInfo(background, &session.GetUserInfoRequest{})
+	if err != nil {
+		return err
+	}
+	if !info.LoggedIn {
+		return errors.New("argocd token is not authenticated")
+	}
+
+	return nil
+}
 
-			if data.Status.Sync.Status == v1alpha1.SyncStatusCodeSynced && data.Status.Health.Status == health.HealthStatusHealthy {
-				logger.Info("Argocd application synced")
+func (ctx *Argocd) getManaged(
+	background context.Context,
+	client application.ApplicationServiceClient,
+	instance string,
+) (*v1alpha1.Application, error) {
+	data, err := client.Get(background, &application.ApplicationQuery{
+		Name:         &instance,
+		AppNamespace: &ctx.metaNamespace,
+	})
+	if err != nil {
+		logger.Error("failed to get Argocd application", zap.Error(err))
+
+		return nil, convertError(err)
+	}
+
+	if data.Labels[models.LabelManagedBy] != models.ManagedBy {
+		return nil, models.NewError(models.ErrApplicationNotFound, "application '%s' is not managed", instance)
+	}
+
+	return data, nil
+}
+
+func (ctx *Argocd) toApplication(item *v1alpha1.Application) models.Application {
+	name := strings.ToLower(item.Name)
 
-				return nil
-			}
+	return models.Application{
+		Name:      name,
+		Service:   lo.CoalesceOrEmpty(item.Labels[models.LabelService], name),
+		Instance:  lo.CoalesceOrEmpty(item.Labels[models.LabelInstance], name),
+		Version:   item.Spec.Source.TargetRevision,
+		Namespace: item.Spec.Destination.Namespace,
+		Cluster:   ctx.clusterName(item.Spec.Destination),
+		Values:    helmValues(item.Spec.Source.Helm),
+		Status: models.ApplicationStatus{
+			Sync:         string(item.Status.Sync.Status),
+			Health:       string(item.Status.Health.Status),
+			Revision:     item.Status.Sync.Revision,
+			ReconciledAt: lo.FromPtr(item.Status.ReconciledAt).Time,
+		},
+	}
+}
+
+func helmParameters(values map[string]string) []v1alpha1.HelmParameter {
+	return lo.MapToSlice(values, func(key string, value string) v1alpha1.HelmParameter {
+		return v1alpha1.HelmParameter{
+			Name:        key,
+			Value:       value,
+			ForceString: false,
 		}
+	})
+}
+
+func mergeHelmParameters(parameters []v1alpha1.HelmParameter, values map[string]string) []v1alpha1.HelmParameter {
+	merged := lo.Reject(parameters, func(item v1alpha1.HelmParameter, _ int) bool {
+		_, ok := values[item.Name]
+
+		return ok
+	})
+
+	return append(merged, helmParameters(values)...)
+}
+
+func helmValues(helm *v1alpha1.ApplicationSourceHelm) map[string]string {
+	if helm == nil {
+		return map[string]string{}
+	}
+
+	return lo.SliceToMap(helm.Parameters, func(item v1alpha1.HelmParameter) (string, string) {
+		return item.Name, item.Value
+	})
+}
+
+func convertError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return models.NewError(models.ErrApplicationNotFound, "%s", status.Convert(err).Message())
+	case codes.AlreadyExists:
+		return models.NewError(models.ErrApplicationExists, "%s", status.Convert(err).Message())
+	default:
+		return err
+	}
+}
+
+func ownershipLabels(request models.DeploymentRequest) map[string]string {
+	return map[string]string{
+		models.LabelManagedBy: models.ManagedBy,
+		models.LabelService:   request.Service,
+		models.LabelInstance:  request.Instance,
+		models.LabelNamespace: request.Namespace,
+		models.LabelVersion:   labelValue(request.Version),
 	}
 }
+
+func identityAnnotations(request models.DeploymentRequest) map[string]string {
+	return lo.OmitByValues(map[string]string{
+		models.AnnotationRequestedBy: request.Requester.User,
+		models.AnnotationTeam:        request.Requester.Team,
+		models.AnnotationOrigin:      request.Requester.System,
+		models.AnnotationJob:         request.Job,
+	}, []string{""})
+}
+
+func sameSyncWindow(left, right v1alpha1.SyncWindow) bool {
+	leftData, _ := json.Marshal(left)
+	rightData, _ := json.Marshal(right)
+
+	return string(leftData) == string(rightData)
+}
+
+func labelValue(value string) string {
+	value = invalidLabelCharacters.ReplaceAllString(value, "_")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+
+	return strings.Trim(value, "_.-")
+}
+
+func (ctx *Argocd) clusterServers(patterns []string) []string {
+	servers := lo.Uniq(lo.FlatMap(patterns, func(pattern string, _ int) []string {
+		matches := lo.Values(lo.PickBy(ctx.clusters, func(name string, _ string) bool {
+			matched, _ := path.Match(pattern, name)
+
+			return matched
+		}))
+
+		return lo.Ternary(len(matches) > 0, matches, []string{pattern})
+	}))
+	sort.Strings(servers)
+
+	return servers
+}
+
+func (ctx *Argocd) clusterName(destination v1alpha1.ApplicationDestination) string {
+	if destination.Name != "" {
+		return destination.Name
+	}
+
+	name, ok := lo.FindKey(ctx.clusters, destination.Server)
+	if !ok {
+		return destination.Server
+	}
+
+	return name
+}
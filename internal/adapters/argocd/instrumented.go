@@ -0,0 +1,118 @@
+package argocd
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/pkg/metrics"
+	"tera/deployment/pkg/tracing"
+	"time"
+)
+
+type instrumented struct {
+	next ports.Argocd
+}
+
+func (ctx *instrumented) GetList(background context.Context) ([]models.Application, error) {
+	background, span, started := ctx.start(background, "list", "")
+	applications, err := ctx.next.GetList(background)
+	ctx.end(span, "list", started, err)
+
+	return applications, err
+}
+
+func (ctx *instrumented) Get(background context.Context, instance string) (*models.Application, error) {
+	background, span, started := ctx.start(background, "get", instance)
+	application, err := ctx.next.Get(background, instance)
+	ctx.end(span, "get", started, err)
+
+	return application, err
+}
+
+func (ctx *instrumented) Create(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	background, span, started := ctx.start(background, "create", request.Instance)
+	application, err := ctx.next.Create(background, request)
+	ctx.end(span, "create", started, err)
+
+	return application, err
+}
+
+func (ctx *instrumented) Update(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	background, span, started := ctx.start(background, "update", request.Instance)
+	application, err := ctx.next.Update(background, request)
+	ctx.end(span, "update", started, err)
+
+	return application, err
+}
+
+func (ctx *instrumented) Delete(background context.Context, instance string) error {
+	background, span, started := ctx.start(background, "delete", instance)
+	err := ctx.next.Delete(background, instance)
+	ctx.end(span, "delete", started, err)
+
+	return err
+}
+
+func (ctx *instrumented) History(background context.Context, instance string) ([]models.Revision, error) {
+	background, span, started := ctx.start(background, "history", instance)
+	history, err := ctx.next.History(background, instance)
+	ctx.end(span, "history", started, err)
+
+	return history, err
+}
+
+func (ctx *instrumented) Adopt(background context.Context, request models.DeploymentRequest) (*models.Application, error) {
+	background, span, started := ctx.start(background, "adopt", request.Instance)
+	application, err := ctx.next.Adopt(background, request)
+	ctx.end(span, "adopt", started, err)
+
+	return application, err
+}
+
+func (ctx *instrumented) Sync(background context.Context, instance string) error {
+	background, span, started := ctx.start(background, "sync", instance)
+	err := ctx.next.Sync(background, instance)
+	ctx.end(span, "sync", started, err)
+
+	return err
+}
+
+func (ctx *instrumented) SyncWindows(background context.Context, windows []models.SyncWindow) error {
+	background, span, started := ctx.start(background, "sync_windows", "")
+	err := ctx.next.SyncWindows(background, windows)
+	ctx.end(span, "sync_windows", started, err)
+
+	return err
+}
+
+func (ctx *instrumented) Ping(background context.Context) error {
+	background, span, started := ctx.start(background, "ping", "")
+	err := ctx.next.Ping(background)
+	ctx.end(span, "ping", started, err)
+
+	return err
+}
+
+func (ctx *instrumented) start(
+	background context.Context,
+	method string,
+	instance string,
+) (context.Context, trace.Span, time.Time) {
+	background, span := tracing.Start(
+		background,
+		"argocd."+method,
+		trace.SpanKindClient,
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", "application.ApplicationService"),
+		attribute.String("deployment.instance", instance),
+	)
+
+	return background, span, time.Now()
+}
+
+func (ctx *instrumented) end(span trace.Span, method string, started time.Time, err error) {
+	metrics.ObserveArgocd(method, started, err)
+	tracing.End(span, err)
+}
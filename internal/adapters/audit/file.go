@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+)
+
+type File struct {
+	path  string
+	file  *os.File
+	mutex sync.Mutex
+}
+
+func NewFileAuditLog(conf *config.Config) ports.AuditLog {
+	if err := os.MkdirAll(filepath.Dir(conf.Audit.Path), 0o755); err != nil {
+		logger.Error("failed to create audit log directory", zap.Error(err))
+
+		panic(err)
+	}
+
+	file, err := os.OpenFile(conf.Audit.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Error("failed to open audit log", zap.String("path", conf.Audit.Path), zap.Error(err))
+
+		panic(err)
+	}
+
+	return &File{
+		path: conf.Audit.Path,
+		file: file,
+	}
+}
+
+func (ctx *File) Append(entry models.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit entry")
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if _, err = ctx.file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write audit entry")
+	}
+
+	return ctx.file.Sync()
+}
+
+func (ctx *File) Entries() ([]models.AuditEntry, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	file, err := os.Open(ctx.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open audit log")
+	}
+	defer file.Close()
+
+	var entries []models.AuditEntry
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, errors.Wrapf(err, "failed to parse audit entry %d", len(entries)+1)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, errors.Wrap(scanner.Err(), "failed to read audit log")
+}
+
+func (ctx *File) Close() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.file.Close()
+}
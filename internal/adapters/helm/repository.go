@@ -0,0 +1,124 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"tera/deployment/internal/ports"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const valuesSchemaFile = "values.schema.json"
+
+type Repository struct {
+	client *http.Client
+	url    string
+}
+
+type repositoryIndex struct {
+	Entries map[string][]repositoryChart `yaml:"entries"`
+}
+
+type repositoryChart struct {
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+}
+
+func NewChartRepository(conf *config.Config) ports.ChartRepository {
+	return &Repository{
+		client: &http.Client{Timeout: 30 * time.Second},
+		url:    strings.TrimSuffix(conf.Argocd.Repository, "/"),
+	}
+}
+
+func (ctx *Repository) GetValuesSchema(chart, version string) ([]byte, error) {
+	archiveURL, err := ctx.findArchive(chart, version)
+	if err != nil {
+		logger.Error("failed to find chart archive", zap.String("chart", chart), zap.Error(err))
+
+		return nil, err
+	}
+
+	response, err := ctx.get(archiveURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Close()
+
+	archive, err := gzip.NewReader(response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read chart archive")
+	}
+	defer archive.Close()
+
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read chart archive")
+		}
+
+		if header.Name == path.Join(chart, valuesSchemaFile) {
+			return io.ReadAll(reader)
+		}
+	}
+}
+
+func (ctx *Repository) findArchive(chart, version string) (string, error) {
+	response, err := ctx.get(ctx.url + "/index.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer response.Close()
+
+	var index repositoryIndex
+	if err = yaml.NewDecoder(response).Decode(&index); err != nil {
+		return "", errors.Wrap(err, "failed to parse repository index")
+	}
+
+	entry, ok := lo.Find(index.Entries[chart], func(item repositoryChart) bool {
+		return version == "" || item.Version == version
+	})
+	if !ok || len(entry.URLs) == 0 {
+		return "", errors.New(fmt.Sprintf("chart '%s' with version '%s' not found", chart, version))
+	}
+
+	archiveURL, err := url.Parse(entry.URLs[0])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid chart url")
+	}
+	if archiveURL.IsAbs() {
+		return archiveURL.String(), nil
+	}
+
+	return ctx.url + "/" + strings.TrimPrefix(archiveURL.String(), "/"), nil
+}
+
+func (ctx *Repository) get(target string) (io.ReadCloser, error) {
+	response, err := ctx.client.Get(target)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request chart repository")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+
+		return nil, errors.New(fmt.Sprintf("chart repository responded with status %d", response.StatusCode))
+	}
+
+	return response.Body, nil
+}
@@ -1,25 +1,36 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 	"strings"
+	"sync"
 	"tera/deployment/internal/domain/models"
 	"tera/deployment/internal/ports"
 	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/metrics"
+	"time"
 )
 
 type Consumer struct {
 	consumer *kafka.Consumer
 	topic    string
+	verifier *verifier
+	health   health
+	mutex    sync.Mutex
+	member   bool
+	paused   bool
+	full     bool
 }
 
-func NewKafkaConsumer(conf *config.Config) ports.KafkaConsumer {
+func NewKafkaConsumer(conf *config.Config, storage ports.Storage) ports.KafkaConsumer {
 	bootstrapServers := lo.Map(conf.Kafka.BootstrapServers, func(server config.KafkaBootstrapServerConfig, _ int) string {
 		return fmt.Sprintf("%s:%d", server.Host, server.Port)
 	})
@@ -45,35 +56,63 @@ func NewKafkaConsumer(conf *config.Config) ports.KafkaConsumer {
 	return &Consumer{
 		consumer: consumer,
 		topic:    conf.Kafka.Topic,
+		verifier: newVerifier(conf.Kafka.Signing, storage),
 	}
 }
 
+// Start polls without joining the consumer group; Subscribe joins it once this
+// replica leads, so that only the leader receives commands.
 func (ctx *Consumer) Start(events chan<- any) error {
-	if err := ctx.consumer.Subscribe(ctx.topic, nil); err != nil {
-		return err
-	}
-
 	go func() {
 		logger.Info("consumer started", zap.String("topic", ctx.topic))
 
 		for {
-			switch event := ctx.consumer.Poll(100).(type) {
+			event := ctx.consumer.Poll(100)
+			ctx.health.tick()
+
+			switch event := event.(type) {
+			case nil:
+				ctx.health.idle()
 			case *kafka.Message:
+				ctx.health.success()
+
 				if len(lo.FilterMap(event.Headers, func(item kafka.Header, _ int) (kafka.Header, bool) {
 					return item, item.Key == applicationHeader.Key && string(item.Value) == string(applicationHeader.Value)
 				})) != 0 {
 					continue
 				}
 
+				key, err := ctx.verifier.verify(event)
+				if err != nil {
+					ctx.reject(events, event, err)
+					continue
+				}
+
 				var message *models.KafkaMessage
-				if err := json.Unmarshal(event.Value, &message); err != nil {
+				if err = json.Unmarshal(event.Value, &message); err != nil {
 					logger.Warn("failed to unmarshal event", zap.Error(err))
+
+					metrics.KafkaFailed.WithLabelValues("consume", "unmarshal").Inc()
+					continue
+				}
+
+				if key == nil {
+					message.Requester.System = models.SystemKafka
+				} else if message.Requester, err = key.bind(message.Requester); err != nil {
+					ctx.reject(events, event, err)
 					continue
 				}
 
-				events <- message
+				message.Context = otel.GetTextMapPropagator().Extract(
+					context.Background(),
+					headerCarrier{headers: &event.Headers},
+				)
+
+				ctx.handoff(events, message)
 			case kafka.Error:
 				logger.Error("kafka error", zap.Error(event))
+
+				ctx.health.failure(event)
 			}
 		}
 	}()
@@ -81,6 +120,204 @@ func (ctx *Consumer) Start(events chan<- any) error {
 	return nil
 }
 
+func (ctx *Consumer) Subscribe() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if err := ctx.consumer.Subscribe(ctx.topic, ctx.rebalance); err != nil {
+		logger.Error("failed to subscribe consumer", zap.String("topic", ctx.topic), zap.Error(err))
+
+		return errors.Wrap(err, "failed to subscribe consumer")
+	}
+	ctx.member = true
+
+	logger.Info("consumer subscribed", zap.String("topic", ctx.topic))
+
+	return nil
+}
+
+func (ctx *Consumer) Unsubscribe() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if !ctx.member {
+		return nil
+	}
+	ctx.member = false
+
+	if err := ctx.consumer.Unsubscribe(); err != nil {
+		logger.Error("failed to unsubscribe consumer", zap.String("topic", ctx.topic), zap.Error(err))
+
+		return errors.Wrap(err, "failed to unsubscribe consumer")
+	}
+	ctx.health.join(false)
+
+	logger.Info("consumer unsubscribed", zap.String("topic", ctx.topic))
+
+	return nil
+}
+
+func (ctx *Consumer) reject(events chan<- any, message *kafka.Message, err error) {
+	securityEvent := &models.SecurityEvent{
+		Reason:    models.SecurityReasonInvalid,
+		Message:   err.Error(),
+		Topic:     lo.FromPtr(message.TopicPartition.Topic),
+		Partition: message.TopicPartition.Partition,
+		Offset:    int64(message.TopicPartition.Offset),
+		Time:      time.Now(),
+	}
+
+	var verificationErr *verificationError
+	if errors.As(err, &verificationErr) {
+		securityEvent.Reason = verificationErr.reason
+		securityEvent.KeyID = verificationErr.keyID
+	}
+
+	logger.Warn(
+		"rejected kafka message",
+		zap.String("reason", securityEvent.Reason),
+		zap.String("key", securityEvent.KeyID),
+		zap.Int32("partition", securityEvent.Partition),
+		zap.Int64("offset", securityEvent.Offset),
+		zap.Error(err),
+	)
+
+	metrics.KafkaFailed.WithLabelValues("consume", securityEvent.Reason).Inc()
+
+	ctx.handoff(events, &models.SystemMessage{
+		Key:   models.SecurityViolation,
+		Value: securityEvent,
+	})
+}
+
+func (ctx *Consumer) handoff(events chan<- any, message any) {
+	select {
+	case events <- message:
+		return
+	default:
+	}
+
+	logger.Warn("event queue is full, waiting to hand off message", zap.String("topic", ctx.topic))
+
+	ctx.throttle(true)
+	ctx.health.handoff(true)
+	events <- message
+	ctx.health.handoff(false)
+	ctx.throttle(false)
+}
+
+func (ctx *Consumer) rebalance(consumer *kafka.Consumer, event kafka.Event) error {
+	switch event := event.(type) {
+	case kafka.AssignedPartitions:
+		logger.Info("joined consumer group", zap.String("topic", ctx.topic), zap.Int("partitions", len(event.Partitions)))
+
+		if err := ctx.assign(consumer, event.Partitions); err != nil {
+			logger.Error("failed to assign partitions", zap.String("topic", ctx.topic), zap.Error(err))
+
+			return err
+		}
+		ctx.health.join(true)
+	case kafka.RevokedPartitions:
+		if consumer.AssignmentLost() {
+			logger.Warn("lost consumer group membership", zap.String("topic", ctx.topic))
+
+			ctx.health.join(false)
+		}
+	}
+
+	return nil
+}
+
+// assign takes the partitions over itself so that the ones assigned while the
+// consumer is paused or the event queue is full start paused as well.
+func (ctx *Consumer) assign(consumer *kafka.Consumer, partitions []kafka.TopicPartition) error {
+	var err error
+	if consumer.GetRebalanceProtocol() == "COOPERATIVE" {
+		err = consumer.IncrementalAssign(partitions)
+	} else {
+		err = consumer.Assign(partitions)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if !ctx.paused && !ctx.full {
+		return nil
+	}
+
+	logger.Warn("pausing assigned partitions", zap.String("topic", ctx.topic), zap.Int("partitions", len(partitions)))
+
+	return consumer.Pause(partitions)
+}
+
+func (ctx *Consumer) throttle(full bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.full = full
+	if err := ctx.apply(); err != nil {
+		logger.Error("failed to throttle consumer", zap.Error(err))
+	}
+}
+
+func (ctx *Consumer) Pause() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.paused = true
+
+	return ctx.apply()
+}
+
+func (ctx *Consumer) Resume() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.paused = false
+
+	return ctx.apply()
+}
+
+func (ctx *Consumer) apply() error {
+	partitions, err := ctx.consumer.Assignment()
+	if err != nil {
+		return errors.Wrap(err, "failed to get consumer assignment")
+	}
+
+	if ctx.paused || ctx.full {
+		logger.Warn("pausing consumer", zap.String("topic", ctx.topic))
+
+		return ctx.consumer.Pause(partitions)
+	}
+
+	logger.Info("resuming consumer", zap.String("topic", ctx.topic))
+
+	return ctx.consumer.Resume(partitions)
+}
+
+func (ctx *Consumer) Alive() models.HealthCheck {
+	return models.NewHealthCheck("kafka_consumer", ctx.health.alive())
+}
+
+func (ctx *Consumer) Health() models.HealthCheck {
+	if err := ctx.health.check(); err != nil {
+		return models.NewHealthCheck("kafka_consumer", err)
+	}
+
+	ctx.mutex.Lock()
+	member := ctx.member
+	ctx.mutex.Unlock()
+
+	if member && !ctx.health.joined() {
+		return models.NewHealthCheck("kafka_consumer", errors.New("not a member of the consumer group"))
+	}
+
+	return models.NewHealthCheck("kafka_consumer", nil)
+}
+
 func (ctx *Consumer) Close() error {
 	if !ctx.consumer.IsClosed() {
 		if err := ctx.consumer.Close(); err != nil {
@@ -1,8 +1,39 @@
 package kafka
 
-import "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+import (
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/samber/lo"
+)
 
 var applicationHeader = kafka.Header{
 	Key:   "source",
 	Value: []byte("tera-deployment-server"),
 }
+
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (ctx headerCarrier) Get(key string) string {
+	header, ok := lo.Find(*ctx.headers, func(item kafka.Header) bool {
+		return item.Key == key
+	})
+	if !ok {
+		return ""
+	}
+
+	return string(header.Value)
+}
+
+func (ctx headerCarrier) Set(key, value string) {
+	*ctx.headers = lo.Reject(*ctx.headers, func(item kafka.Header, _ int) bool {
+		return item.Key == key
+	})
+	*ctx.headers = append(*ctx.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (ctx headerCarrier) Keys() []string {
+	return lo.Map(*ctx.headers, func(item kafka.Header, _ int) string {
+		return item.Key
+	})
+}
@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"sync"
+	"time"
+)
+
+const (
+	errorWindow = 30 * time.Second
+	stallWindow = time.Minute
+)
+
+type health struct {
+	mutex     sync.Mutex
+	active    time.Time
+	blocked   time.Time
+	succeeded time.Time
+	failed    time.Time
+	failing   time.Time
+	err       error
+	fatal     error
+	member    bool
+}
+
+func (ctx *health) tick() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.active = time.Now()
+}
+
+func (ctx *health) idle() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.failing = time.Time{}
+}
+
+func (ctx *health) success() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.succeeded = time.Now()
+	ctx.failing = time.Time{}
+}
+
+func (ctx *health) failure(err error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.failed = time.Now()
+	ctx.err = err
+	if ctx.failing.IsZero() {
+		ctx.failing = ctx.failed
+	}
+
+	if kafkaErr, ok := err.(kafka.Error); ok && kafkaErr.IsFatal() {
+		ctx.fatal = err
+	}
+}
+
+func (ctx *health) handoff(blocked bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.blocked = time.Time{}
+	if blocked {
+		ctx.blocked = time.Now()
+	}
+}
+
+func (ctx *health) join(member bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.member = member
+}
+
+func (ctx *health) joined() bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.member
+}
+
+// alive tells a poll loop that is stuck apart from one that is only waiting
+// for the processor to accept a message, which is normal backpressure.
+func (ctx *health) alive() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	switch {
+	case ctx.fatal != nil:
+		return fmt.Errorf("fatal error: %v", ctx.fatal)
+	case !ctx.blocked.IsZero():
+		return nil
+	case !ctx.active.IsZero() && time.Since(ctx.active) > stallWindow:
+		return fmt.Errorf("no activity since %s", ctx.active.Format(time.RFC3339))
+	case !ctx.failing.IsZero() && time.Since(ctx.failing) > stallWindow:
+		return fmt.Errorf("failing since %s: %v", ctx.failing.Format(time.RFC3339), ctx.err)
+	default:
+		return nil
+	}
+}
+
+func (ctx *health) check() error {
+	if err := ctx.alive(); err != nil {
+		return err
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.err != nil && ctx.failed.After(ctx.succeeded) && time.Since(ctx.failed) < errorWindow {
+		return fmt.Errorf("recent error: %v", ctx.err)
+	}
+
+	return nil
+}
@@ -1,22 +1,29 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"strings"
 	"tera/deployment/internal/domain/models"
 	"tera/deployment/internal/ports"
 	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/tracing"
 )
 
 type Producer struct {
 	producer *kafka.Producer
 	topic    string
+	security string
+	health   health
 }
 
 func NewKafkaProducer(conf *config.Config) ports.KafkaProducer {
@@ -42,13 +49,34 @@ func NewKafkaProducer(conf *config.Config) ports.KafkaProducer {
 		panic(err)
 	}
 
-	return &Producer{
+	result := &Producer{
 		producer: producer,
 		topic:    conf.Kafka.Topic,
+		security: lo.CoalesceOrEmpty(conf.Kafka.SecurityTopic, conf.Kafka.Topic),
 	}
+	go result.watch()
+
+	return result
+}
+
+func (ctx *Producer) Produce(background context.Context, key models.Key, value any) error {
+	background, span := tracing.Start(background, fmt.Sprintf("kafka.produce %s", key.Value), trace.SpanKindProducer,
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", ctx.topicFor(key)),
+	)
+
+	err := ctx.produce(background, key, value)
+	if err != nil {
+		ctx.health.failure(err)
+	} else {
+		ctx.health.success()
+	}
+	tracing.End(span, err)
+
+	return err
 }
 
-func (ctx *Producer) Produce(key models.Key, value any) error {
+func (ctx *Producer) produce(background context.Context, key models.Key, value any) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		logger.Error("failed to marshal message value to JSON", zap.Error(err))
@@ -56,6 +84,11 @@ func (ctx *Producer) Produce(key models.Key, value any) error {
 		return errors.New(fmt.Sprintf("failed to marshal value: %v", err))
 	}
 
+	topic := ctx.topicFor(key)
+
+	headers := []kafka.Header{applicationHeader}
+	otel.GetTextMapPropagator().Inject(background, headerCarrier{headers: &headers})
+
 	events := make(chan kafka.Event)
 	defer close(events)
 
@@ -63,10 +96,10 @@ func (ctx *Producer) Produce(key models.Key, value any) error {
 		Key:   []byte(key.Value),
 		Value: data,
 		TopicPartition: kafka.TopicPartition{
-			Topic:     lo.ToPtr(ctx.topic),
+			Topic:     lo.ToPtr(topic),
 			Partition: kafka.PartitionAny,
 		},
-		Headers: []kafka.Header{applicationHeader},
+		Headers: headers,
 	}, events)
 	if err != nil {
 		logger.Error("failed to produce Kafka message", zap.Error(err))
@@ -78,19 +111,19 @@ func (ctx *Producer) Produce(key models.Key, value any) error {
 	case *kafka.Message:
 		if e.TopicPartition.Error != nil {
 			logger.Error("failed to produce message to Kafka topic",
-				zap.String("topic", ctx.topic),
+				zap.String("topic", topic),
 				zap.Error(e.TopicPartition.Error),
 			)
 			return errors.New(fmt.Sprintf("failed to produce message: %v", e.TopicPartition.Error))
 		}
 		logger.Info("successfully produced message",
-			zap.String("topic", ctx.topic),
+			zap.String("topic", topic),
 			zap.String("key", key.Value),
 			zap.Any("value", value),
 		)
 	case *kafka.Error:
 		logger.Error("Kafka error during message production",
-			zap.String("topic", ctx.topic),
+			zap.String("topic", topic),
 			zap.Error(e),
 		)
 		return errors.New(fmt.Sprintf("kafka error: %v", e))
@@ -103,3 +136,25 @@ func (ctx *Producer) Produce(key models.Key, value any) error {
 
 	return nil
 }
+
+func (ctx *Producer) topicFor(key models.Key) string {
+	if key == models.SecurityViolation {
+		return ctx.security
+	}
+
+	return ctx.topic
+}
+
+func (ctx *Producer) Health() models.HealthCheck {
+	return models.NewHealthCheck("kafka_producer", ctx.health.check())
+}
+
+func (ctx *Producer) watch() {
+	for event := range ctx.producer.Events() {
+		if err, ok := event.(kafka.Error); ok {
+			logger.Error("kafka producer error", zap.Error(err))
+
+			ctx.health.failure(err)
+		}
+	}
+}
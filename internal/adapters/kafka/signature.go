@@ -0,0 +1,246 @@
+package kafka
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"path"
+	"strconv"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const (
+	headerKeyID     = "x-tera-key-id"
+	headerSignature = "x-tera-signature"
+	headerTimestamp = "x-tera-timestamp"
+	headerNonce     = "x-tera-nonce"
+)
+
+const (
+	algorithmHMAC    = "hmac-sha256"
+	algorithmEd25519 = "ed25519"
+	defaultMaxSkew   = 5 * time.Minute
+)
+
+type signingKey struct {
+	id        string
+	algorithm string
+	secret    []byte
+	publicKey ed25519.PublicKey
+	users     []string
+	teams     []string
+	system    string
+}
+
+// nonceStore claims a nonce until it expires; it is the storage port, so that a
+// restart or a new leader still rejects replays within the allowed skew.
+type nonceStore interface {
+	ClaimNonce(nonce string, expires time.Time) (bool, error)
+}
+
+type verifier struct {
+	keys     map[string]signingKey
+	required bool
+	maxSkew  time.Duration
+	nonces   nonceStore
+}
+
+type verificationError struct {
+	reason  string
+	keyID   string
+	message string
+}
+
+func (err *verificationError) Error() string {
+	return err.message
+}
+
+func newVerifier(conf config.KafkaSigningConfig, nonces nonceStore) *verifier {
+	if len(conf.Keys) > 0 && !conf.Required {
+		logger.Error("signing keys are configured but unsigned commands are still accepted")
+
+		panic("kafka.signing.required must be true when signing keys are configured")
+	}
+
+	keys := make(map[string]signingKey, len(conf.Keys))
+	for _, item := range conf.Keys {
+		key := signingKey{
+			id:     item.ID,
+			users:  item.Users,
+			teams:  item.Teams,
+			system: lo.CoalesceOrEmpty(item.System, models.SystemKafka),
+		}
+
+		switch strings.ToLower(item.Algorithm) {
+		case algorithmHMAC:
+			if item.Secret == "" {
+				logger.Error("missing secret for signing key", zap.String("key", item.ID))
+
+				panic(fmt.Sprintf("signing key '%s' has no secret", item.ID))
+			}
+
+			key.algorithm = algorithmHMAC
+			key.secret = []byte(item.Secret)
+		case algorithmEd25519:
+			publicKey, err := base64.StdEncoding.DecodeString(item.PublicKey)
+			if err != nil || len(publicKey) != ed25519.PublicKeySize {
+				logger.Error("invalid public key for signing key", zap.String("key", item.ID), zap.Error(err))
+
+				panic(fmt.Sprintf("signing key '%s' has an invalid ed25519 public key", item.ID))
+			}
+
+			key.algorithm = algorithmEd25519
+			key.publicKey = publicKey
+		default:
+			logger.Error("unsupported signing algorithm", zap.String("key", item.ID), zap.String("algorithm", item.Algorithm))
+
+			panic(fmt.Sprintf("signing key '%s' uses unsupported algorithm '%s'", item.ID, item.Algorithm))
+		}
+
+		keys[item.ID] = key
+	}
+
+	maxSkew := conf.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+
+	return &verifier{
+		keys:     keys,
+		required: conf.Required,
+		maxSkew:  maxSkew,
+		nonces:   nonces,
+	}
+}
+
+// verify returns the key a message was signed with, or nil for an unsigned
+// message that is accepted because signatures are not required.
+func (ctx *verifier) verify(message *kafka.Message) (*signingKey, error) {
+	carrier := headerCarrier{headers: &message.Headers}
+
+	keyID, signature := carrier.Get(headerKeyID), carrier.Get(headerSignature)
+	if keyID == "" && signature == "" {
+		if ctx.required {
+			return nil, &verificationError{reason: models.SecurityReasonUnsigned, message: "message is not signed"}
+		}
+
+		return nil, nil
+	}
+
+	key, ok := ctx.keys[keyID]
+	if !ok {
+		return nil, &verificationError{
+			reason:  models.SecurityReasonUnknownID,
+			keyID:   keyID,
+			message: fmt.Sprintf("unknown signing key '%s'", keyID),
+		}
+	}
+
+	invalid := func(message string) error {
+		return &verificationError{reason: models.SecurityReasonInvalid, keyID: keyID, message: message}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, invalid("signature is not valid base64")
+	}
+
+	timestamp, nonce := carrier.Get(headerTimestamp), carrier.Get(headerNonce)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, invalid("timestamp is not a unix time")
+	}
+	if nonce == "" {
+		return nil, invalid("nonce is missing")
+	}
+
+	if !key.verify(signedPayload(timestamp, nonce, message.Value), decoded) {
+		return nil, invalid("signature does not match")
+	}
+
+	issued := time.Unix(seconds, 0)
+	if skew := time.Since(issued); skew > ctx.maxSkew || skew < -ctx.maxSkew {
+		return nil, &verificationError{
+			reason:  models.SecurityReasonStale,
+			keyID:   keyID,
+			message: fmt.Sprintf("timestamp %s is outside the allowed skew of %s", issued.Format(time.RFC3339), ctx.maxSkew),
+		}
+	}
+
+	// The nonce is kept until its timestamp falls out of the allowed skew, after which the
+	// timestamp check rejects replays on its own.
+	claimed, err := ctx.nonces.ClaimNonce(keyID+"/"+nonce, issued.Add(ctx.maxSkew))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to claim nonce")
+	}
+	if !claimed {
+		return nil, &verificationError{
+			reason:  models.SecurityReasonReplayed,
+			keyID:   keyID,
+			message: fmt.Sprintf("nonce '%s' has already been used", nonce),
+		}
+	}
+
+	return &key, nil
+}
+
+func (key signingKey) verify(payload, signature []byte) bool {
+	switch key.algorithm {
+	case algorithmHMAC:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write(payload)
+
+		return hmac.Equal(mac.Sum(nil), signature)
+	case algorithmEd25519:
+		return ed25519.Verify(key.publicKey, payload, signature)
+	default:
+		return false
+	}
+}
+
+// bind checks that a signed command only names principals its key may act
+// for, and pins the system to the key's.
+func (key signingKey) bind(requester models.Identity) (models.Identity, error) {
+	mismatch := func(kind, value string) error {
+		return &verificationError{
+			reason:  models.SecurityReasonPrincipal,
+			keyID:   key.id,
+			message: fmt.Sprintf("signing key '%s' may not act for %s '%s'", key.id, kind, value),
+		}
+	}
+
+	switch {
+	case requester.User != "" && !matchesAny(key.users, requester.User):
+		return requester, mismatch("user", requester.User)
+	case requester.Team != "" && !matchesAny(key.teams, requester.Team):
+		return requester, mismatch("team", requester.Team)
+	case requester.System != "" && requester.System != key.system:
+		return requester, mismatch("system", requester.System)
+	}
+
+	requester.System = key.system
+
+	return requester, nil
+}
+
+func matchesAny(patterns []string, value string) bool {
+	return lo.SomeBy(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, value)
+
+		return matched
+	})
+}
+
+func signedPayload(timestamp, nonce string, value []byte) []byte {
+	return append([]byte(timestamp+"\n"+nonce+"\n"), value...)
+}
@@ -0,0 +1,249 @@
+package kafka
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"strconv"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+	"time"
+)
+
+const testSecret = "secret"
+
+type memoryNonces map[string]time.Time
+
+func (ctx memoryNonces) ClaimNonce(nonce string, expires time.Time) (bool, error) {
+	if expiry, ok := ctx[nonce]; ok && time.Now().Before(expiry) {
+		return false, nil
+	}
+	ctx[nonce] = expires
+
+	return true, nil
+}
+
+func signedMessage(keyID string, sign func(payload []byte) []byte, issued time.Time, nonce string) *kafka.Message {
+	value := []byte(`{"action":"fetch"}`)
+	timestamp := strconv.FormatInt(issued.Unix(), 10)
+
+	return &kafka.Message{
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: headerKeyID, Value: []byte(keyID)},
+			{Key: headerSignature, Value: []byte(base64.StdEncoding.EncodeToString(sign(signedPayload(timestamp, nonce, value))))},
+			{Key: headerTimestamp, Value: []byte(timestamp)},
+			{Key: headerNonce, Value: []byte(nonce)},
+		},
+	}
+}
+
+func hmacSign(secret string) func(payload []byte) []byte {
+	return func(payload []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+
+		return mac.Sum(nil)
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signing := config.KafkaSigningConfig{
+		Required: true,
+		MaxSkew:  time.Minute,
+		Keys: []config.KafkaSigningKeyConfig{
+			{ID: "ci", Algorithm: algorithmHMAC, Secret: testSecret},
+			{ID: "ops", Algorithm: algorithmEd25519, PublicKey: base64.StdEncoding.EncodeToString(publicKey)},
+		},
+	}
+	edSign := func(payload []byte) []byte {
+		return ed25519.Sign(privateKey, payload)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		signing  config.KafkaSigningConfig
+		messages []*kafka.Message
+		reason   string
+		key      string
+	}{
+		{
+			name:     "unsigned message when signatures are optional",
+			signing:  config.KafkaSigningConfig{},
+			messages: []*kafka.Message{{Value: []byte(`{}`)}},
+		},
+		{
+			name:     "unsigned message when signatures are required",
+			signing:  signing,
+			messages: []*kafka.Message{{Value: []byte(`{}`)}},
+			reason:   models.SecurityReasonUnsigned,
+		},
+		{
+			name:     "valid hmac signature",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ci", hmacSign(testSecret), now, "n1")},
+			key:      "ci",
+		},
+		{
+			name:     "valid ed25519 signature",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ops", edSign, now, "n1")},
+			key:      "ops",
+		},
+		{
+			name:     "unknown key",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("other", hmacSign(testSecret), now, "n1")},
+			reason:   models.SecurityReasonUnknownID,
+		},
+		{
+			name:     "bad hmac signature",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ci", hmacSign("wrong"), now, "n1")},
+			reason:   models.SecurityReasonInvalid,
+		},
+		{
+			name:     "ed25519 signature of another key",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ops", hmacSign(testSecret), now, "n1")},
+			reason:   models.SecurityReasonInvalid,
+		},
+		{
+			name:     "timestamp older than the allowed skew",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ci", hmacSign(testSecret), now.Add(-2*time.Minute), "n1")},
+			reason:   models.SecurityReasonStale,
+		},
+		{
+			name:     "timestamp ahead of the allowed skew",
+			signing:  signing,
+			messages: []*kafka.Message{signedMessage("ci", hmacSign(testSecret), now.Add(2*time.Minute), "n1")},
+			reason:   models.SecurityReasonStale,
+		},
+		{
+			name:    "replayed nonce",
+			signing: signing,
+			messages: []*kafka.Message{
+				signedMessage("ci", hmacSign(testSecret), now, "n1"),
+				signedMessage("ci", hmacSign(testSecret), now, "n1"),
+			},
+			reason: models.SecurityReasonReplayed,
+		},
+		{
+			name:    "same nonce under another key",
+			signing: signing,
+			messages: []*kafka.Message{
+				signedMessage("ci", hmacSign(testSecret), now, "n1"),
+				signedMessage("ops", edSign, now, "n1"),
+			},
+			key: "ops",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := newVerifier(test.signing, memoryNonces{})
+
+			var (
+				key *signingKey
+				err error
+			)
+			for _, message := range test.messages {
+				key, err = verifier.verify(message)
+			}
+
+			if test.reason == "" {
+				if err != nil {
+					t.Fatalf("expected the message to verify, got %v", err)
+				}
+				if (key == nil) != (test.key == "") || (key != nil && key.id != test.key) {
+					t.Fatalf("expected key %q, got %+v", test.key, key)
+				}
+
+				return
+			}
+
+			var verificationErr *verificationError
+			if !errors.As(err, &verificationErr) || verificationErr.reason != test.reason {
+				t.Fatalf("expected reason %q, got %v", test.reason, err)
+			}
+		})
+	}
+}
+
+func TestSigningKeyBind(t *testing.T) {
+	key := signingKey{id: "ci", users: []string{"deploy-*"}, teams: []string{"platform"}, system: "ci"}
+
+	tests := []struct {
+		name      string
+		key       signingKey
+		requester models.Identity
+		expected  models.Identity
+		mismatch  bool
+	}{
+		{
+			name:      "allowed user and team",
+			key:       key,
+			requester: models.Identity{User: "deploy-bot", Team: "platform"},
+			expected:  models.Identity{User: "deploy-bot", Team: "platform", System: "ci"},
+		},
+		{
+			name:      "matching system",
+			key:       key,
+			requester: models.Identity{System: "ci"},
+			expected:  models.Identity{System: "ci"},
+		},
+		{
+			name:      "user outside the key",
+			key:       key,
+			requester: models.Identity{User: "alice"},
+			mismatch:  true,
+		},
+		{
+			name:      "team outside the key",
+			key:       key,
+			requester: models.Identity{User: "deploy-bot", Team: "payments"},
+			mismatch:  true,
+		},
+		{
+			name:      "claimed system",
+			key:       key,
+			requester: models.Identity{System: models.SystemReconciler},
+			mismatch:  true,
+		},
+		{
+			name:      "key without principals",
+			key:       signingKey{id: "bare", system: models.SystemKafka},
+			requester: models.Identity{User: "alice"},
+			mismatch:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requester, err := test.key.bind(test.requester)
+			if test.mismatch {
+				var verificationErr *verificationError
+				if !errors.As(err, &verificationErr) || verificationErr.reason != models.SecurityReasonPrincipal {
+					t.Fatalf("expected a principal mismatch, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil || requester != test.expected {
+				t.Fatalf("expected %+v, got %+v (%v)", test.expected, requester, err)
+			}
+		})
+	}
+}
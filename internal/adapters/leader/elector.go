@@ -0,0 +1,65 @@
+package leader
+
+import (
+	"go.uber.org/zap"
+	"os"
+	"strings"
+	"tera/deployment/internal/ports"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const (
+	modeLease = "lease"
+	modeFile  = "file"
+
+	retryPeriod = 2 * time.Second
+)
+
+func NewLeaderElector(conf *config.Config) ports.LeaderElector {
+	identity := conf.Leader.Identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			logger.Error("failed to resolve hostname for leader identity", zap.Error(err))
+
+			panic(err)
+		}
+
+		identity = hostname
+	}
+
+	switch strings.ToLower(conf.Leader.Mode) {
+	case modeLease:
+		return newLeaseElector(conf, identity)
+	case modeFile:
+		return newFileElector(conf, identity)
+	default:
+		return &Standalone{identity: identity}
+	}
+}
+
+type Standalone struct {
+	identity string
+	stopped  func()
+}
+
+func (ctx *Standalone) Run(started func(), stopped func()) error {
+	ctx.stopped = stopped
+	started()
+
+	return nil
+}
+
+func (ctx *Standalone) Leader() string {
+	return ctx.identity
+}
+
+func (ctx *Standalone) Close() error {
+	if ctx.stopped != nil {
+		ctx.stopped()
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package leader
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+type File struct {
+	path     string
+	identity string
+	file     *os.File
+	done     chan struct{}
+	group    sync.WaitGroup
+}
+
+func newFileElector(conf *config.Config, identity string) *File {
+	return &File{
+		path:     conf.Leader.Path,
+		identity: identity,
+		done:     make(chan struct{}),
+	}
+}
+
+func (ctx *File) Run(started func(), stopped func()) error {
+	if err := os.MkdirAll(filepath.Dir(ctx.path), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create leader lock directory")
+	}
+
+	file, err := os.OpenFile(ctx.path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open leader lock file")
+	}
+	ctx.file = file
+
+	ctx.group.Add(1)
+	go func() {
+		defer ctx.group.Done()
+
+		ticker := time.NewTicker(retryPeriod)
+		defer ticker.Stop()
+
+		for {
+			if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+				_ = file.Truncate(0)
+				_, _ = file.WriteAt([]byte(ctx.identity), 0)
+
+				logger.Info("leader elected", zap.String("leader", ctx.identity), zap.String("path", ctx.path))
+				started()
+
+				<-ctx.done
+				stopped()
+
+				_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+				return
+			}
+
+			select {
+			case <-ctx.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Leader reads the identity the leader wrote into the lock file.
+func (ctx *File) Leader() string {
+	data, err := os.ReadFile(ctx.path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+func (ctx *File) Close() error {
+	close(ctx.done)
+	ctx.group.Wait()
+
+	if ctx.file == nil {
+		return nil
+	}
+
+	return ctx.file.Close()
+}
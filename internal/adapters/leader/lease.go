@@ -0,0 +1,112 @@
+package leader
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sync"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Lease struct {
+	lock     *resourcelock.LeaseLock
+	identity string
+	leader   string
+	mutex    sync.Mutex
+	cancel   context.CancelFunc
+	group    sync.WaitGroup
+}
+
+func newLeaseElector(conf *config.Config, identity string) *Lease {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		logger.Error("failed to load in-cluster Kubernetes config", zap.Error(err))
+
+		panic(err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		logger.Error("failed to create Kubernetes client", zap.Error(err))
+
+		panic(err)
+	}
+
+	return &Lease{
+		lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      conf.Leader.Name,
+				Namespace: conf.Leader.Namespace,
+			},
+			Client: client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: identity,
+			},
+		},
+		identity: identity,
+	}
+}
+
+func (ctx *Lease) Run(started func(), stopped func()) error {
+	background, cancel := context.WithCancel(context.Background())
+	ctx.cancel = cancel
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            ctx.lock,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            ctx.lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { started() },
+			OnStoppedLeading: stopped,
+			OnNewLeader: func(identity string) {
+				logger.Info("leader elected", zap.String("leader", identity), zap.String("identity", ctx.identity))
+
+				ctx.mutex.Lock()
+				ctx.leader = identity
+				ctx.mutex.Unlock()
+			},
+		},
+	})
+	if err != nil {
+		cancel()
+
+		return err
+	}
+
+	ctx.group.Add(1)
+	go func() {
+		defer ctx.group.Done()
+
+		for background.Err() == nil {
+			elector.Run(background)
+		}
+	}()
+
+	return nil
+}
+
+func (ctx *Lease) Leader() string {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.leader
+}
+
+func (ctx *Lease) Close() error {
+	if ctx.cancel != nil {
+		ctx.cancel()
+	}
+	ctx.group.Wait()
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"net/http"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/logger"
+)
+
+type badRequestError struct {
+	err error
+}
+
+func (err *badRequestError) Error() string {
+	return "invalid request: " + err.err.Error()
+}
+
+func writeError(writer http.ResponseWriter, err error) {
+	var pendingErr *models.ApprovalPendingError
+	if errors.As(err, &pendingErr) {
+		writeJSON(writer, http.StatusAccepted, toApprovalResponse(pendingErr.Approval))
+		return
+	}
+
+	var deferredErr *models.DeferredError
+	if errors.As(err, &deferredErr) {
+		writeJSON(writer, http.StatusAccepted, toDeferralResponse(deferredErr.Deferral))
+		return
+	}
+
+	status := statusCode(err)
+	if status == http.StatusInternalServerError {
+		logger.Error("http request failed", zap.Error(err))
+	}
+
+	response := ErrorResponse{
+		Error: err.Error(),
+	}
+
+	if violations := models.ViolationsOf(err); len(violations) > 0 {
+		response.Violations = lo.Map(violations, func(item models.Violation, _ int) ViolationResponse {
+			return ViolationResponse{
+				Path:    item.Path,
+				Message: item.Message,
+			}
+		})
+	}
+
+	writeJSON(writer, status, response)
+}
+
+func statusCode(err error) int {
+	var badRequestErr *badRequestError
+
+	switch {
+	case errors.As(err, &badRequestErr):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrServiceNotFound),
+		errors.Is(err, models.ErrApplicationNotFound),
+		errors.Is(err, models.ErrJobNotFound),
+		errors.Is(err, models.ErrApprovalNotFound),
+		errors.Is(err, models.ErrScheduleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrApplicationExists),
+		errors.Is(err, models.ErrServiceMismatch),
+		errors.Is(err, models.ErrDependencyMissing),
+		errors.Is(err, models.ErrDependentsDeployed),
+		errors.Is(err, models.ErrOperationInProgress),
+		errors.Is(err, models.ErrApprovalClosed),
+		errors.Is(err, models.ErrDeploymentFrozen),
+		errors.Is(err, models.ErrScheduleClosed):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrClusterNotAllowed),
+		errors.Is(err, models.ErrPermissionDenied),
+		errors.Is(err, models.ErrAdmissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidValues):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrNotLeader):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+func newUpgrader(origins []string) *websocket.Upgrader {
+	upgrader := &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	if len(origins) > 0 {
+		upgrader.CheckOrigin = func(request *http.Request) bool {
+			origin := request.Header.Get("Origin")
+
+			return origin == "" || lo.ContainsBy(origins, func(item string) bool {
+				return strings.EqualFold(strings.TrimSuffix(item, "/"), origin)
+			})
+		}
+	}
+
+	return upgrader
+}
+
+type EventResponse struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+func (ctx *Server) streamEvents(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		writeError(writer, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	events, unsubscribe := ctx.broker.Subscribe(eventFilter(request))
+	defer unsubscribe()
+	requester := models.RequesterFrom(request.Context())
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		case message, ok := <-events:
+			if !ok {
+				return
+			}
+
+			value, visible := ctx.authorizer.Visible(requester, message.Value)
+			if !visible {
+				continue
+			}
+
+			data, err := json.Marshal(value)
+			if err != nil {
+				logger.Warn("failed to marshal event", zap.Error(err))
+				continue
+			}
+
+			if _, err = fmt.Fprintf(writer, "event: %s\ndata: %s\n\n", message.Key.Value, data); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (ctx *Server) streamEventsWebSocket(writer http.ResponseWriter, request *http.Request) {
+	connection, err := ctx.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		logger.Warn("failed to upgrade websocket connection", zap.Error(err))
+		return
+	}
+	defer connection.Close()
+
+	events, unsubscribe := ctx.broker.Subscribe(eventFilter(request))
+	defer unsubscribe()
+	requester := models.RequesterFrom(request.Context())
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := connection.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case message, ok := <-events:
+			if !ok {
+				return
+			}
+
+			value, visible := ctx.authorizer.Visible(requester, message.Value)
+			if !visible {
+				continue
+			}
+
+			if err := connection.WriteJSON(EventResponse{Key: message.Key.Value, Value: value}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func eventFilter(request *http.Request) models.EventFilter {
+	query := request.URL.Query()
+
+	return models.EventFilter{
+		Services:   queryValues(query["service"]),
+		Namespaces: queryValues(query["namespace"]),
+		Types:      queryValues(query["type"]),
+		Replay:     query.Get("replay") != "false",
+	}
+}
+
+func queryValues(values []string) []string {
+	return lo.Compact(lo.FlatMap(values, func(item string, _ int) []string {
+		return strings.Split(item, ",")
+	}))
+}
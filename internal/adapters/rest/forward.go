@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"github.com/samber/lo"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+)
+
+const headerForwarded = "X-Tera-Forwarded"
+
+type forwarder struct {
+	leadership usecases.Leadership
+	template   string
+	mutex      sync.Mutex
+	proxies    map[string]*httputil.ReverseProxy
+}
+
+func newForwarder(leadership usecases.Leadership, template string) *forwarder {
+	return &forwarder{
+		leadership: leadership,
+		template:   template,
+		proxies:    make(map[string]*httputil.ReverseProxy),
+	}
+}
+
+// wrap serves requests on the leader and hands them to the leader everywhere
+// else; probes and metrics always describe the replica that answers them.
+func (ctx *forwarder) wrap(next http.Handler) http.Handler {
+	local := []string{"/healthz", "/readyz", "/metrics", "/openapi.json"}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if ctx.leadership.IsLeader() || request.Method == http.MethodOptions || lo.Contains(local, request.URL.Path) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		leader := ctx.leadership.Leader()
+		if ctx.template == "" || leader == "" || request.Header.Get(headerForwarded) != "" {
+			writeError(writer, models.NewError(models.ErrNotLeader, "this replica is not the leader, retry against '%s'", leader))
+			return
+		}
+
+		proxy, err := ctx.proxy(strings.ReplaceAll(ctx.template, "{identity}", leader))
+		if err != nil {
+			writeError(writer, err)
+			return
+		}
+
+		request.Header.Set(headerForwarded, "true")
+		proxy.ServeHTTP(writer, request)
+	})
+}
+
+func (ctx *forwarder) proxy(address string) (*httputil.ReverseProxy, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if proxy, ok := ctx.proxies[address]; ok {
+		return proxy, nil
+	}
+
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.FlushInterval = -1
+	ctx.proxies[address] = proxy
+
+	return proxy, nil
+}
@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pathParameterPattern = regexp.MustCompile(`\{(\w+)}`)
+
+type schemaBuilder struct {
+	schemas map[string]any
+}
+
+func openAPIDocument(routes []route) map[string]any {
+	builder := &schemaBuilder{schemas: make(map[string]any)}
+
+	paths := make(map[string]map[string]any)
+	for _, item := range routes {
+		if paths[item.path] == nil {
+			paths[item.path] = make(map[string]any)
+		}
+
+		paths[item.path][strings.ToLower(item.method)] = builder.operation(item)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Tera Deployment Server",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.schemas,
+		},
+	}
+}
+
+func (ctx *schemaBuilder) operation(item route) map[string]any {
+	operation := map[string]any{
+		"summary": item.summary,
+		"responses": map[string]any{
+			strconv.Itoa(item.status): ctx.response(http.StatusText(item.status), item.response),
+			"default":                 ctx.response("Error", ErrorResponse{}),
+		},
+	}
+
+	var parameters []any
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(item.path, -1) {
+		parameters = append(parameters, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	for _, name := range item.query {
+		parameters = append(parameters, map[string]any{
+			"name":   name,
+			"in":     "query",
+			"schema": map[string]any{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if item.request != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": ctx.schema(reflect.TypeOf(item.request)),
+				},
+			},
+		}
+	}
+
+	return operation
+}
+
+func (ctx *schemaBuilder) response(description string, body any) map[string]any {
+	response := map[string]any{
+		"description": description,
+	}
+
+	if body != nil {
+		response["content"] = map[string]any{
+			"application/json": map[string]any{
+				"schema": ctx.schema(reflect.TypeOf(body)),
+			},
+		}
+	}
+
+	return response
+}
+
+func (ctx *schemaBuilder) schema(value reflect.Type) map[string]any {
+	if value == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		return ctx.schema(value.Elem())
+	case reflect.Struct:
+		if _, ok := ctx.schemas[value.Name()]; !ok {
+			ctx.schemas[value.Name()] = nil
+			ctx.schemas[value.Name()] = ctx.object(value)
+		}
+
+		return map[string]any{"$ref": "#/components/schemas/" + value.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": ctx.schema(value.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": ctx.schema(value.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (ctx *schemaBuilder) object(value reflect.Type) map[string]any {
+	properties := make(map[string]any)
+
+	var required []string
+	for idx := 0; idx < value.NumField(); idx++ {
+		field := value.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = ctx.schema(field.Type)
+		if field.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+	}
+
+	object := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+
+	return object
+}
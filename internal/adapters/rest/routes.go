@@ -0,0 +1,496 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+	"net/http"
+	"net/url"
+	"strconv"
+	"tera/deployment/internal/domain/models"
+	"time"
+)
+
+const (
+	headerAuthorization = "Authorization"
+	headerUser          = "X-Tera-User"
+	headerTeam          = "X-Tera-Team"
+)
+
+type route struct {
+	method   string
+	path     string
+	query    []string
+	summary  string
+	status   int
+	request  any
+	response any
+	handler  func(request *http.Request) (any, error)
+}
+
+func (ctx *Server) applicationRoutes() []route {
+	return []route{
+		{
+			method:   http.MethodGet,
+			path:     "/applications",
+			summary:  "List deployed applications",
+			status:   http.StatusOK,
+			response: []ApplicationResponse{},
+			handler:  ctx.listApplications,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/applications/{instance}",
+			summary:  "Get a deployed application",
+			status:   http.StatusOK,
+			response: ApplicationResponse{},
+			handler:  ctx.getApplication,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/applications",
+			summary:  "Create an application",
+			status:   http.StatusCreated,
+			request:  CreateApplicationRequest{},
+			response: ApplicationResponse{},
+			handler:  ctx.createApplication,
+		},
+		{
+			method:   http.MethodPut,
+			path:     "/applications/{instance}",
+			summary:  "Upgrade an application",
+			status:   http.StatusOK,
+			request:  UpgradeApplicationRequest{},
+			response: ApplicationResponse{},
+			handler:  ctx.upgradeApplication,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/applications/{instance}/history",
+			summary:  "List deployed revisions of an application",
+			status:   http.StatusOK,
+			response: []RevisionResponse{},
+			handler:  ctx.applicationHistory,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/applications/{instance}/rollback",
+			summary:  "Roll an application back to a previous revision",
+			status:   http.StatusOK,
+			request:  RollbackApplicationRequest{},
+			response: ApplicationResponse{},
+			handler:  ctx.rollbackApplication,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/applications/{instance}/sync",
+			summary:  "Sync an application with its desired state",
+			status:   http.StatusOK,
+			request:  SyncApplicationRequest{},
+			response: ApplicationResponse{},
+			handler:  ctx.syncApplication,
+		},
+		{
+			method:  http.MethodDelete,
+			path:    "/applications/{instance}",
+			query:   []string{"override"},
+			summary: "Delete an application",
+			status:  http.StatusNoContent,
+			handler: ctx.deleteApplication,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/graph",
+			summary:  "Get the service dependency graph",
+			status:   http.StatusOK,
+			response: GraphResponse{},
+			handler:  ctx.graph,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/history",
+			query:    []string{"service", "namespace", "result", "from", "to", "limit"},
+			summary:  "Query the deployment history",
+			status:   http.StatusOK,
+			response: []HistoryRecordResponse{},
+			handler:  ctx.history,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/jobs/{id}",
+			summary:  "Get a deployment job",
+			status:   http.StatusOK,
+			response: JobResponse{},
+			handler:  ctx.getJob,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/approvals",
+			query:    []string{"state"},
+			summary:  "List deployment approvals",
+			status:   http.StatusOK,
+			response: []ApprovalResponse{},
+			handler:  ctx.listApprovals,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/approvals/{id}",
+			summary:  "Get a deployment approval",
+			status:   http.StatusOK,
+			response: ApprovalResponse{},
+			handler:  ctx.getApproval,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/approvals/{id}/approve",
+			summary:  "Approve a pending deployment",
+			status:   http.StatusOK,
+			request:  ApprovalDecisionRequest{},
+			response: ApprovalResponse{},
+			handler:  ctx.approve,
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/approvals/{id}/reject",
+			summary:  "Reject a pending deployment",
+			status:   http.StatusOK,
+			request:  ApprovalDecisionRequest{},
+			response: ApprovalResponse{},
+			handler:  ctx.reject,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/freeze",
+			summary:  "List freeze windows and deferred deployments",
+			status:   http.StatusOK,
+			response: FreezeResponse{},
+			handler:  ctx.freezeStatus,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/audit/verify",
+			summary:  "Verify the hash chain of the audit log",
+			status:   http.StatusOK,
+			response: AuditVerificationResponse{},
+			handler:  ctx.verifyAudit,
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/reconcile/plan",
+			summary:  "Compare the desired state with deployed applications",
+			status:   http.StatusOK,
+			response: PlanResponse{},
+			handler:  ctx.plan,
+		},
+	}
+}
+
+func (ctx *Server) listApplications(request *http.Request) (any, error) {
+	applications, err := ctx.manager.GetList(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(applications, func(item models.Application, _ int) ApplicationResponse {
+		return toApplicationResponse(item)
+	}), nil
+}
+
+func (ctx *Server) getApplication(request *http.Request) (any, error) {
+	application, err := ctx.manager.Get(request.Context(), request.PathValue("instance"))
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(*application), nil
+}
+
+func (ctx *Server) createApplication(request *http.Request) (any, error) {
+	var body CreateApplicationRequest
+	if err := decode(request, &body); err != nil {
+		return nil, err
+	}
+
+	application, err := ctx.manager.Create(request.Context(), models.DeploymentRequest{
+		Service:   body.Service,
+		Instance:  body.Instance,
+		Version:   body.Version,
+		Namespace: body.Namespace,
+		Cluster:   body.Cluster,
+		Requester: ctx.requester(request, body.Requester),
+		Values:    body.Values,
+		Override:  body.Override,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(*application), nil
+}
+
+func (ctx *Server) upgradeApplication(request *http.Request) (any, error) {
+	var body UpgradeApplicationRequest
+	if err := decode(request, &body); err != nil {
+		return nil, err
+	}
+
+	application, err := ctx.manager.Upgrade(request.Context(), models.DeploymentRequest{
+		Service:   body.Service,
+		Instance:  request.PathValue("instance"),
+		Version:   body.Version,
+		Requester: ctx.requester(request, body.Requester),
+		Values:    body.Values,
+		Replace:   body.Replace,
+		Override:  body.Override,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(*application), nil
+}
+
+func (ctx *Server) applicationHistory(request *http.Request) (any, error) {
+	history, err := ctx.manager.History(request.Context(), request.PathValue("instance"))
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(history, func(item models.Revision, _ int) RevisionResponse {
+		return toRevisionResponse(item)
+	}), nil
+}
+
+func (ctx *Server) rollbackApplication(request *http.Request) (any, error) {
+	var body RollbackApplicationRequest
+	if err := decode(request, &body); err != nil {
+		return nil, err
+	}
+
+	application, err := ctx.manager.Rollback(request.Context(), models.DeploymentRequest{
+		Instance:  request.PathValue("instance"),
+		Requester: ctx.requester(request, body.Requester),
+		Override:  body.Override,
+	}, body.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(*application), nil
+}
+
+func (ctx *Server) syncApplication(request *http.Request) (any, error) {
+	var body SyncApplicationRequest
+	if err := decode(request, &body); err != nil {
+		return nil, err
+	}
+
+	application, err := ctx.manager.Sync(request.Context(), models.DeploymentRequest{
+		Instance:  request.PathValue("instance"),
+		Requester: ctx.requester(request, body.Requester),
+		Override:  body.Override,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(*application), nil
+}
+
+func (ctx *Server) deleteApplication(request *http.Request) (any, error) {
+	override, err := strconv.ParseBool(lo.CoalesceOrEmpty(request.URL.Query().Get("override"), "false"))
+	if err != nil {
+		return nil, &badRequestError{err: err}
+	}
+
+	return nil, ctx.manager.Delete(request.Context(), models.DeploymentRequest{
+		Instance:  request.PathValue("instance"),
+		Requester: ctx.requester(request, ""),
+		Override:  override,
+	})
+}
+
+func (ctx *Server) graph(request *http.Request) (any, error) {
+	graph, err := ctx.manager.Graph(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return toGraphResponse(graph), nil
+}
+
+func (ctx *Server) history(request *http.Request) (any, error) {
+	query, err := historyQuery(request.URL.Query())
+	if err != nil {
+		return nil, &badRequestError{err: err}
+	}
+
+	records, err := ctx.historian.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	records = ctx.visible(request, records).([]models.HistoryRecord)
+
+	return lo.Map(records, func(item models.HistoryRecord, _ int) HistoryRecordResponse {
+		return toHistoryRecordResponse(item)
+	}), nil
+}
+
+func (ctx *Server) freezeStatus(request *http.Request) (any, error) {
+	deferrals, err := ctx.freeze.Deferrals()
+	if err != nil {
+		return nil, err
+	}
+	deferrals = ctx.visible(request, deferrals).([]models.Deferral)
+
+	return FreezeResponse{
+		Windows: lo.Map(ctx.freeze.Windows(), func(item models.FreezeWindow, _ int) FreezeWindowResponse {
+			return toFreezeWindowResponse(item)
+		}),
+		Deferred: lo.Map(deferrals, func(item models.Deferral, _ int) DeferralResponse {
+			return toDeferralResponse(&item)
+		}),
+	}, nil
+}
+
+func (ctx *Server) getJob(request *http.Request) (any, error) {
+	job, err := ctx.jobs.Get(request.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.fetch(request, job); err != nil {
+		return nil, err
+	}
+
+	return toJobResponse(job), nil
+}
+
+func (ctx *Server) listApprovals(request *http.Request) (any, error) {
+	approvals, err := ctx.approvals.List(request.URL.Query().Get("state"))
+	if err != nil {
+		return nil, err
+	}
+	approvals = ctx.visible(request, approvals).([]models.Approval)
+
+	return lo.Map(approvals, func(item models.Approval, _ int) ApprovalResponse {
+		return toApprovalResponse(&item)
+	}), nil
+}
+
+func (ctx *Server) getApproval(request *http.Request) (any, error) {
+	approval, err := ctx.approvals.Get(request.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.fetch(request, approval); err != nil {
+		return nil, err
+	}
+
+	return toApprovalResponse(approval), nil
+}
+
+func (ctx *Server) approve(request *http.Request) (any, error) {
+	return ctx.decide(request, ctx.manager.Approve)
+}
+
+func (ctx *Server) reject(request *http.Request) (any, error) {
+	return ctx.decide(request, ctx.manager.Reject)
+}
+
+func (ctx *Server) decide(
+	request *http.Request,
+	decide func(context.Context, string, models.Identity, string) (*models.Approval, error),
+) (any, error) {
+	var body ApprovalDecisionRequest
+	if err := decode(request, &body); err != nil {
+		return nil, err
+	}
+
+	approval, err := decide(request.Context(), request.PathValue("id"), ctx.requester(request, body.Requester), body.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return toApprovalResponse(approval), nil
+}
+
+func (ctx *Server) verifyAudit(_ *http.Request) (any, error) {
+	return toAuditVerificationResponse(ctx.audit.Verify()), nil
+}
+
+func (ctx *Server) plan(request *http.Request) (any, error) {
+	plan, err := ctx.planner.Plan(request.Context())
+	if err != nil {
+		return nil, err
+	}
+	plan = ctx.visible(request, plan).(*models.Plan)
+
+	return toPlanResponse(plan), nil
+}
+
+func historyQuery(values url.Values) (models.HistoryQuery, error) {
+	query := models.HistoryQuery{
+		Service:   values.Get("service"),
+		Namespace: values.Get("namespace"),
+		Result:    values.Get("result"),
+	}
+
+	for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := values.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("invalid '%s' parameter, expected RFC 3339 time", name)
+			}
+
+			*target = parsed
+		}
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return query, fmt.Errorf("invalid 'limit' parameter, expected a positive integer")
+		}
+
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// visible trims a list to the entries the requester may fetch.
+func (ctx *Server) visible(request *http.Request, value any) any {
+	visible, _ := ctx.authorizer.Visible(models.RequesterFrom(request.Context()), value)
+
+	return visible
+}
+
+func (ctx *Server) fetch(request *http.Request, value any) error {
+	if _, ok := ctx.authorizer.Visible(models.RequesterFrom(request.Context()), value); !ok {
+		return models.NewError(models.ErrPermissionDenied, "not allowed to fetch '%s'", request.PathValue("id"))
+	}
+
+	return nil
+}
+
+// requester lets the body name the user only while no token vouches for it.
+func (ctx *Server) requester(request *http.Request, user string) models.Identity {
+	identity := models.RequesterFrom(request.Context())
+	if !ctx.authenticator.Enabled() {
+		identity.User = lo.CoalesceOrEmpty(user, identity.User)
+	}
+
+	return identity
+}
+
+func decode(request *http.Request, body any) error {
+	decoder := json.NewDecoder(request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(body); err != nil {
+		return &badRequestError{err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/metrics"
+	"tera/deployment/pkg/tracing"
+	"time"
+)
+
+type Server struct {
+	server        *http.Server
+	manager       usecases.DeploymentManager
+	historian     usecases.History
+	jobs          usecases.JobTracker
+	planner       usecases.Reconciler
+	broker        usecases.EventBroker
+	health        usecases.HealthChecker
+	audit         usecases.Audit
+	approvals     usecases.Approvals
+	freeze        usecases.Freeze
+	authenticator usecases.Authenticator
+	authorizer    usecases.Authorizer
+	upgrader      *websocket.Upgrader
+	routes        []route
+}
+
+func NewServer(
+	conf *config.Config,
+	manager usecases.DeploymentManager,
+	history usecases.History,
+	jobs usecases.JobTracker,
+	planner usecases.Reconciler,
+	broker usecases.EventBroker,
+	health usecases.HealthChecker,
+	audit usecases.Audit,
+	approvals usecases.Approvals,
+	freeze usecases.Freeze,
+	authenticator usecases.Authenticator,
+	authorizer usecases.Authorizer,
+	leadership usecases.Leadership,
+) ports.HTTPServer {
+	server := &Server{
+		manager:       manager,
+		historian:     history,
+		jobs:          jobs,
+		planner:       planner,
+		broker:        broker,
+		health:        health,
+		audit:         audit,
+		approvals:     approvals,
+		freeze:        freeze,
+		authenticator: authenticator,
+		authorizer:    authorizer,
+		upgrader:      newUpgrader(conf.HTTP.AllowedOrigins),
+	}
+	server.routes = server.applicationRoutes()
+
+	mux := http.NewServeMux()
+	for _, item := range server.routes {
+		mux.HandleFunc(item.method+" "+item.path, server.handle(item))
+	}
+	mux.HandleFunc("GET /openapi.json", server.openapi)
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", server.probe(server.health.Liveness))
+	mux.HandleFunc("GET /readyz", server.probe(server.health.Readiness))
+	mux.HandleFunc("GET /events", server.authenticated(server.streamEvents))
+	mux.HandleFunc("GET /events/ws", server.authenticated(server.streamEventsWebSocket))
+
+	server.server = &http.Server{
+		Addr:              conf.HTTP.Address,
+		Handler:           newForwarder(leadership, conf.Leader.HTTPURL).wrap(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server
+}
+
+func (ctx *Server) Start() error {
+	listener, err := net.Listen("tcp", ctx.server.Addr)
+	if err != nil {
+		logger.Error("failed to listen http server", zap.String("address", ctx.server.Addr), zap.Error(err))
+
+		return err
+	}
+
+	go func() {
+		logger.Info("http server started", zap.String("address", ctx.server.Addr))
+
+		if err := ctx.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http server stopped", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+func (ctx *Server) Close() error {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return ctx.server.Shutdown(shutdownCtx)
+}
+
+func (ctx *Server) handle(item route) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		background, span := tracing.Start(
+			otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header)),
+			item.method+" "+item.path,
+			trace.SpanKindServer,
+			attribute.String("http.request.method", item.method),
+			attribute.String("http.route", item.path),
+		)
+
+		identity, err := ctx.authenticate(request)
+		if err != nil {
+			tracing.End(span, err)
+			writeError(writer, err)
+			return
+		}
+
+		background = models.WithRequester(background, identity)
+
+		response, err := item.handler(request.WithContext(background))
+		tracing.End(span, err)
+		if err != nil {
+			writeError(writer, err)
+			return
+		}
+
+		if response == nil {
+			writer.WriteHeader(item.status)
+			return
+		}
+
+		writeJSON(writer, item.status, response)
+	}
+}
+
+func (ctx *Server) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		identity, err := ctx.authenticate(request)
+		if err != nil {
+			writeError(writer, err)
+			return
+		}
+
+		handler(writer, request.WithContext(models.WithRequester(request.Context(), identity)))
+	}
+}
+
+// authenticate binds the requester to a bearer token once tokens are configured;
+// until then the identity headers are taken at face value. The system is always
+// this adapter, whatever the caller claims.
+func (ctx *Server) authenticate(request *http.Request) (models.Identity, error) {
+	if !ctx.authenticator.Enabled() {
+		return models.Identity{
+			User:   request.Header.Get(headerUser),
+			Team:   request.Header.Get(headerTeam),
+			System: models.SystemREST,
+		}, nil
+	}
+
+	token, _ := strings.CutPrefix(request.Header.Get(headerAuthorization), "Bearer ")
+	identity, err := ctx.authenticator.Authenticate(token)
+	if err != nil {
+		return models.Identity{}, err
+	}
+	identity.System = models.SystemREST
+
+	return identity, nil
+}
+
+func (ctx *Server) probe(check func() models.HealthReport) http.HandlerFunc {
+	return func(writer http.ResponseWriter, _ *http.Request) {
+		report := check()
+
+		status := http.StatusOK
+		if !report.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeJSON(writer, status, toHealthResponse(report))
+	}
+}
+
+func (ctx *Server) openapi(writer http.ResponseWriter, _ *http.Request) {
+	writeJSON(writer, http.StatusOK, openAPIDocument(ctx.routes))
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		logger.Warn("failed to write http response", zap.Error(err))
+	}
+}
@@ -0,0 +1,434 @@
+package rest
+
+import (
+	"github.com/samber/lo"
+	"tera/deployment/internal/domain/models"
+	"time"
+)
+
+type ApplicationResponse struct {
+	Name      string                    `json:"name"`
+	Service   string                    `json:"service"`
+	Instance  string                    `json:"instance"`
+	Version   string                    `json:"version"`
+	Namespace string                    `json:"namespace"`
+	Cluster   string                    `json:"cluster"`
+	Status    ApplicationStatusResponse `json:"status"`
+	Job       string                    `json:"job,omitempty"`
+}
+
+type ApplicationStatusResponse struct {
+	Sync   string `json:"sync"`
+	Health string `json:"health"`
+}
+
+type CreateApplicationRequest struct {
+	Service   string            `json:"service" required:"true"`
+	Instance  string            `json:"instance,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Cluster   string            `json:"cluster,omitempty"`
+	Requester string            `json:"requester,omitempty"`
+	Values    map[string]string `json:"values,omitempty"`
+	Override  bool              `json:"override,omitempty"`
+}
+
+type UpgradeApplicationRequest struct {
+	Service   string            `json:"service,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Requester string            `json:"requester,omitempty"`
+	Values    map[string]string `json:"values,omitempty"`
+	Replace   bool              `json:"replace,omitempty"`
+	Override  bool              `json:"override,omitempty"`
+}
+
+type RollbackApplicationRequest struct {
+	Revision  int64  `json:"revision,omitempty"`
+	Requester string `json:"requester,omitempty"`
+	Override  bool   `json:"override,omitempty"`
+}
+
+type SyncApplicationRequest struct {
+	Requester string `json:"requester,omitempty"`
+	Override  bool   `json:"override,omitempty"`
+}
+
+type RevisionResponse struct {
+	ID         int64             `json:"id"`
+	Version    string            `json:"version"`
+	Values     map[string]string `json:"values"`
+	DeployedAt time.Time         `json:"deployed_at"`
+}
+
+type GraphResponse struct {
+	Nodes []GraphNodeResponse `json:"nodes"`
+}
+
+type GraphNodeResponse struct {
+	Service   string                `json:"service"`
+	Version   string                `json:"version"`
+	Depends   []DependencyResponse  `json:"depends"`
+	Instances []ApplicationResponse `json:"instances"`
+}
+
+type DependencyResponse struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+}
+
+type HistoryRecordResponse struct {
+	Time      time.Time                  `json:"time"`
+	Kind      string                     `json:"kind"`
+	Action    string                     `json:"action"`
+	Requester string                     `json:"requester,omitempty"`
+	Team      string                     `json:"team,omitempty"`
+	System    string                     `json:"system,omitempty"`
+	Service   string                     `json:"service"`
+	Instance  string                     `json:"instance"`
+	Namespace string                     `json:"namespace"`
+	Cluster   string                     `json:"cluster"`
+	Version   string                     `json:"version"`
+	Result    string                     `json:"result"`
+	Message   string                     `json:"message,omitempty"`
+	Status    *ApplicationStatusResponse `json:"status,omitempty"`
+}
+
+type JobResponse struct {
+	ID        string                     `json:"id"`
+	Action    string                     `json:"action"`
+	State     string                     `json:"state"`
+	Service   string                     `json:"service"`
+	Instance  string                     `json:"instance"`
+	Namespace string                     `json:"namespace"`
+	Cluster   string                     `json:"cluster"`
+	Version   string                     `json:"version"`
+	Requester string                     `json:"requester,omitempty"`
+	Team      string                     `json:"team,omitempty"`
+	System    string                     `json:"system,omitempty"`
+	Message   string                     `json:"message,omitempty"`
+	Status    *ApplicationStatusResponse `json:"status,omitempty"`
+	CreatedAt time.Time                  `json:"created_at"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+type PlanResponse struct {
+	Applied bool               `json:"applied"`
+	Steps   []PlanStepResponse `json:"steps"`
+	Time    time.Time          `json:"time"`
+}
+
+type PlanStepResponse struct {
+	Action      string `json:"action"`
+	Service     string `json:"service"`
+	Instance    string `json:"instance"`
+	Namespace   string `json:"namespace"`
+	Cluster     string `json:"cluster"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type ApprovalResponse struct {
+	ID          string                 `json:"id"`
+	Action      string                 `json:"action"`
+	State       string                 `json:"state"`
+	Service     string                 `json:"service"`
+	Instance    string                 `json:"instance"`
+	Namespace   string                 `json:"namespace"`
+	Cluster     string                 `json:"cluster"`
+	Version     string                 `json:"version"`
+	FromVersion string                 `json:"from_version,omitempty"`
+	Values      map[string]string      `json:"values,omitempty"`
+	Requester   string                 `json:"requester,omitempty"`
+	Required    int                    `json:"required"`
+	Approvers   []ApprovalVoteResponse `json:"approvers,omitempty"`
+	Rejecter    *ApprovalVoteResponse  `json:"rejecter,omitempty"`
+	Job         string                 `json:"job,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+}
+
+type ApprovalVoteResponse struct {
+	Requester string    `json:"requester"`
+	Team      string    `json:"team,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type ApprovalDecisionRequest struct {
+	Requester string `json:"requester,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+type FreezeResponse struct {
+	Windows  []FreezeWindowResponse `json:"windows"`
+	Deferred []DeferralResponse     `json:"deferred"`
+}
+
+type FreezeWindowResponse struct {
+	Name    string    `json:"name"`
+	Mode    string    `json:"mode"`
+	Message string    `json:"message,omitempty"`
+	Active  bool      `json:"active"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+type DeferralResponse struct {
+	ID        string    `json:"id"`
+	Action    string    `json:"action"`
+	Service   string    `json:"service"`
+	Instance  string    `json:"instance"`
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster"`
+	Version   string    `json:"version"`
+	Revision  int64     `json:"revision,omitempty"`
+	Requester string    `json:"requester,omitempty"`
+	Window    string    `json:"window"`
+	ReleaseAt time.Time `json:"release_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ErrorResponse struct {
+	Error      string              `json:"error"`
+	Violations []ViolationResponse `json:"violations,omitempty"`
+}
+
+type ViolationResponse struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type AuditVerificationResponse struct {
+	Valid   bool   `json:"valid"`
+	Entries int    `json:"entries"`
+	Head    string `json:"head,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                `json:"status"`
+	Checks []HealthCheckResponse `json:"checks"`
+}
+
+type HealthCheckResponse struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func toApplicationResponse(application models.Application) ApplicationResponse {
+	return ApplicationResponse{
+		Name:      application.Name,
+		Service:   application.Service,
+		Instance:  application.Instance,
+		Version:   application.Version,
+		Namespace: application.Namespace,
+		Cluster:   application.Cluster,
+		Status: ApplicationStatusResponse{
+			Sync:   application.Status.Sync,
+			Health: application.Status.Health,
+		},
+		Job: application.Job,
+	}
+}
+
+func toRevisionResponse(revision models.Revision) RevisionResponse {
+	return RevisionResponse{
+		ID:         revision.ID,
+		Version:    revision.Version,
+		Values:     revision.Values,
+		DeployedAt: revision.DeployedAt,
+	}
+}
+
+func toHistoryRecordResponse(record models.HistoryRecord) HistoryRecordResponse {
+	response := HistoryRecordResponse{
+		Time:      record.Time,
+		Kind:      record.Kind,
+		Action:    record.Action,
+		Requester: record.Requester.User,
+		Team:      record.Requester.Team,
+		System:    record.Requester.System,
+		Service:   record.Service,
+		Instance:  record.Instance,
+		Namespace: record.Namespace,
+		Cluster:   record.Cluster,
+		Version:   record.Version,
+		Result:    record.Result,
+		Message:   record.Message,
+	}
+	if record.Status != nil {
+		response.Status = &ApplicationStatusResponse{
+			Sync:   record.Status.Sync,
+			Health: record.Status.Health,
+		}
+	}
+
+	return response
+}
+
+func toJobResponse(job *models.Job) JobResponse {
+	response := JobResponse{
+		ID:        job.ID,
+		Action:    job.Action,
+		State:     job.State,
+		Service:   job.Service,
+		Instance:  job.Instance,
+		Namespace: job.Namespace,
+		Cluster:   job.Cluster,
+		Version:   job.Version,
+		Requester: job.Requester.User,
+		Team:      job.Requester.Team,
+		System:    job.Requester.System,
+		Message:   job.Message,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if job.Status != nil {
+		response.Status = &ApplicationStatusResponse{
+			Sync:   job.Status.Sync,
+			Health: job.Status.Health,
+		}
+	}
+
+	return response
+}
+
+func toPlanResponse(plan *models.Plan) PlanResponse {
+	return PlanResponse{
+		Applied: plan.Applied,
+		Steps: lo.Map(plan.Steps, func(item models.PlanStep, _ int) PlanStepResponse {
+			return PlanStepResponse{
+				Action:      item.Action,
+				Service:     item.Service,
+				Instance:    item.Instance,
+				Namespace:   item.Namespace,
+				Cluster:     item.Cluster,
+				FromVersion: item.FromVersion,
+				ToVersion:   item.ToVersion,
+				Error:       item.Error,
+			}
+		}),
+		Time: plan.Time,
+	}
+}
+
+func toGraphResponse(graph *models.DependencyGraph) GraphResponse {
+	return GraphResponse{
+		Nodes: lo.Map(graph.Nodes, func(node models.DependencyNode, _ int) GraphNodeResponse {
+			return GraphNodeResponse{
+				Service: node.Service,
+				Version: node.Version,
+				Depends: lo.Map(node.Depends, func(item models.Application, _ int) DependencyResponse {
+					return DependencyResponse{
+						Service: item.Service,
+						Version: item.Version,
+					}
+				}),
+				Instances: lo.Map(node.Instances, func(item models.Application, _ int) ApplicationResponse {
+					return toApplicationResponse(item)
+				}),
+			}
+		}),
+	}
+}
+
+func toApprovalResponse(approval *models.Approval) ApprovalResponse {
+	toVote := func(item models.ApprovalVote) ApprovalVoteResponse {
+		return ApprovalVoteResponse{
+			Requester: item.Requester.User,
+			Team:      item.Requester.Team,
+			Comment:   item.Comment,
+			Time:      item.Time,
+		}
+	}
+
+	response := ApprovalResponse{
+		ID:          approval.ID,
+		Action:      approval.Action,
+		State:       approval.State,
+		Service:     approval.Request.Service,
+		Instance:    approval.Request.Instance,
+		Namespace:   approval.Request.Namespace,
+		Cluster:     approval.Request.Cluster,
+		Version:     approval.Request.Version,
+		FromVersion: approval.FromVersion,
+		Values:      approval.Request.Values,
+		Requester:   approval.Request.Requester.User,
+		Required:    approval.Required,
+		Approvers: lo.Map(approval.Approvers, func(item models.ApprovalVote, _ int) ApprovalVoteResponse {
+			return toVote(item)
+		}),
+		Job:       approval.Job,
+		Message:   approval.Message,
+		CreatedAt: approval.CreatedAt,
+		ExpiresAt: approval.ExpiresAt,
+	}
+	if approval.Rejecter != nil {
+		response.Rejecter = lo.ToPtr(toVote(*approval.Rejecter))
+	}
+
+	return response
+}
+
+func toAuditVerificationResponse(verification models.AuditVerification) AuditVerificationResponse {
+	return AuditVerificationResponse{
+		Valid:   verification.Valid,
+		Entries: verification.Entries,
+		Head:    verification.Head,
+		Error:   verification.Error,
+	}
+}
+
+func toHealthResponse(report models.HealthReport) HealthResponse {
+	return HealthResponse{
+		Status: healthStatus(report.Healthy),
+		Checks: lo.Map(report.Checks, func(item models.HealthCheck, _ int) HealthCheckResponse {
+			return HealthCheckResponse{
+				Name:      item.Name,
+				Status:    healthStatus(item.Healthy),
+				Message:   item.Message,
+				CheckedAt: item.CheckedAt,
+			}
+		}),
+	}
+}
+
+func healthStatus(healthy bool) string {
+	if healthy {
+		return "up"
+	}
+
+	return "down"
+}
+
+func toFreezeWindowResponse(window models.FreezeWindow) FreezeWindowResponse {
+	return FreezeWindowResponse{
+		Name:    window.Name,
+		Mode:    window.Mode,
+		Message: window.Message,
+		Active:  window.Active,
+		Start:   window.Start,
+		End:     window.End,
+	}
+}
+
+func toDeferralResponse(deferral *models.Deferral) DeferralResponse {
+	return DeferralResponse{
+		ID:        deferral.ID,
+		Action:    deferral.Action,
+		Service:   deferral.Request.Service,
+		Instance:  deferral.Request.Instance,
+		Namespace: deferral.Request.Namespace,
+		Cluster:   deferral.Request.Cluster,
+		Version:   deferral.Request.Version,
+		Revision:  deferral.Revision,
+		Requester: deferral.Request.Requester.User,
+		Window:    deferral.Window,
+		ReleaseAt: deferral.ReleaseAt,
+		CreatedAt: deferral.CreatedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"tera/deployment/internal/domain/models"
+)
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	background context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.background
+}
+
+func (ctx *Server) authenticateUnary(
+	background context.Context,
+	request any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	identity, err := ctx.authenticate(background)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return handler(models.WithRequester(background, identity), request)
+}
+
+func (ctx *Server) authenticateStream(
+	server any,
+	stream grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	identity, err := ctx.authenticate(stream.Context())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	return handler(server, &authenticatedStream{
+		ServerStream: stream,
+		background:   models.WithRequester(stream.Context(), identity),
+	})
+}
+
+// authenticate binds the requester to the bearer token in the authorization
+// metadata once tokens are configured; until then the identity metadata is
+// taken at face value. The system is always this adapter.
+func (ctx *Server) authenticate(background context.Context) (models.Identity, error) {
+	incoming, _ := metadata.FromIncomingContext(background)
+	value := func(key string) string {
+		return lo.FirstOrEmpty(incoming.Get(key))
+	}
+
+	if !ctx.authenticator.Enabled() {
+		return models.Identity{
+			User:   value("x-tera-user"),
+			Team:   value("x-tera-team"),
+			System: models.SystemGRPC,
+		}, nil
+	}
+
+	token, _ := strings.CutPrefix(value("authorization"), "Bearer ")
+	identity, err := ctx.authenticator.Authenticate(token)
+	if err != nil {
+		return models.Identity{}, err
+	}
+	identity.System = models.SystemGRPC
+
+	return identity, nil
+}
+
+// requester lets the request name the user only while no token vouches for it
+// and the metadata does not name one either.
+func (ctx *Server) requester(background context.Context, user string) models.Identity {
+	identity := models.RequesterFrom(background)
+	if !ctx.authenticator.Enabled() {
+		identity.User = lo.CoalesceOrEmpty(identity.User, user)
+	}
+
+	return identity
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"strings"
+	"sync"
+	deploymentv1 "tera/deployment/api/deployment/v1"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+)
+
+const metadataForwarded = "x-tera-forwarded"
+
+type forwarder struct {
+	leadership usecases.Leadership
+	template   string
+	mutex      sync.Mutex
+	conns      map[string]*grpc.ClientConn
+}
+
+func newForwarder(leadership usecases.Leadership, template string) *forwarder {
+	return &forwarder{
+		leadership: leadership,
+		template:   template,
+		conns:      make(map[string]*grpc.ClientConn),
+	}
+}
+
+// leader returns a client of the leader together with the context to call it
+// with, or no client when this replica is the leader and serves the call.
+func (ctx *forwarder) leader(
+	background context.Context,
+) (deploymentv1.DeploymentServiceClient, context.Context, error) {
+	if ctx.leadership.IsLeader() {
+		return nil, background, nil
+	}
+
+	incoming, _ := metadata.FromIncomingContext(background)
+	leader := ctx.leadership.Leader()
+	if ctx.template == "" || leader == "" || len(incoming.Get(metadataForwarded)) > 0 {
+		return nil, background, models.NewError(models.ErrNotLeader, "this replica is not the leader, retry against '%s'", leader)
+	}
+
+	conn, err := ctx.conn(strings.ReplaceAll(ctx.template, "{identity}", leader))
+	if err != nil {
+		return nil, background, err
+	}
+
+	outgoing := metadata.Pairs(metadataForwarded, "true")
+	for _, key := range []string{"authorization", "x-tera-user", "x-tera-team"} {
+		outgoing.Set(key, incoming.Get(key)...)
+	}
+
+	return deploymentv1.NewDeploymentServiceClient(conn), metadata.NewOutgoingContext(background, outgoing), nil
+}
+
+func (ctx *forwarder) conn(address string) (*grpc.ClientConn, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if conn, ok := ctx.conns[address]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	ctx.conns[address] = conn
+
+	return conn, nil
+}
+
+func (ctx *forwarder) Close() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	errs := lo.MapToSlice(ctx.conns, func(_ string, conn *grpc.ClientConn) error {
+		return conn.Close()
+	})
+	clear(ctx.conns)
+
+	return errors.Join(errs...)
+}
+
+func relay[T any](client grpc.ServerStreamingClient[T], stream grpc.ServerStreamingServer[T]) error {
+	for {
+		event, err := client.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+}
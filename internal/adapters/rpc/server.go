@@ -0,0 +1,280 @@
+package rpc
+
+import (
+	"context"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	deploymentv1 "tera/deployment/api/deployment/v1"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const jobPollInterval = 5 * time.Second
+
+type Server struct {
+	deploymentv1.UnimplementedDeploymentServiceServer
+
+	server        *grpc.Server
+	address       string
+	manager       usecases.DeploymentManager
+	jobs          usecases.JobTracker
+	broker        usecases.EventBroker
+	authenticator usecases.Authenticator
+	forwarder     *forwarder
+}
+
+func NewServer(
+	conf *config.Config,
+	manager usecases.DeploymentManager,
+	jobs usecases.JobTracker,
+	broker usecases.EventBroker,
+	authenticator usecases.Authenticator,
+	leadership usecases.Leadership,
+) ports.GRPCServer {
+	server := &Server{
+		address:       conf.GRPC.Address,
+		manager:       manager,
+		jobs:          jobs,
+		broker:        broker,
+		authenticator: authenticator,
+		forwarder:     newForwarder(leadership, conf.Leader.GRPCAddress),
+	}
+	server.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(server.authenticateUnary),
+		grpc.StreamInterceptor(server.authenticateStream),
+	)
+
+	deploymentv1.RegisterDeploymentServiceServer(server.server, server)
+
+	return server
+}
+
+func (ctx *Server) Start() error {
+	listener, err := net.Listen("tcp", ctx.address)
+	if err != nil {
+		logger.Error("failed to listen grpc server", zap.String("address", ctx.address), zap.Error(err))
+
+		return err
+	}
+
+	go func() {
+		logger.Info("grpc server started", zap.String("address", ctx.address))
+
+		if err := ctx.server.Serve(listener); err != nil {
+			logger.Error("grpc server stopped", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+func (ctx *Server) Close() error {
+	stopped := make(chan struct{})
+	go func() {
+		ctx.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		ctx.server.Stop()
+	}
+
+	return ctx.forwarder.Close()
+}
+
+func (ctx *Server) ListApplications(
+	background context.Context,
+	request *deploymentv1.ListApplicationsRequest,
+) (*deploymentv1.ListApplicationsResponse, error) {
+	if leader, outgoing, err := ctx.forwarder.leader(background); leader != nil || err != nil {
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		return leader.ListApplications(outgoing, request)
+	}
+
+	applications, err := ctx.manager.GetList(background)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &deploymentv1.ListApplicationsResponse{
+		Applications: lo.Map(applications, func(item models.Application, _ int) *deploymentv1.Application {
+			return toApplication(&item)
+		}),
+	}, nil
+}
+
+func (ctx *Server) GetApplication(
+	background context.Context,
+	request *deploymentv1.GetApplicationRequest,
+) (*deploymentv1.Application, error) {
+	if leader, outgoing, err := ctx.forwarder.leader(background); leader != nil || err != nil {
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		return leader.GetApplication(outgoing, request)
+	}
+
+	application, err := ctx.manager.Get(background, request.GetInstance())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toApplication(application), nil
+}
+
+func (ctx *Server) Deploy(
+	request *deploymentv1.DeployRequest,
+	stream grpc.ServerStreamingServer[deploymentv1.DeploymentEvent],
+) error {
+	if leader, outgoing, err := ctx.forwarder.leader(stream.Context()); leader != nil || err != nil {
+		if err != nil {
+			return toStatus(err)
+		}
+
+		client, err := leader.Deploy(outgoing, request)
+		if err != nil {
+			return err
+		}
+
+		return relay(client, stream)
+	}
+
+	events, unsubscribe := ctx.broker.Subscribe(models.EventFilter{})
+	defer unsubscribe()
+
+	application, err := ctx.manager.Create(stream.Context(), models.DeploymentRequest{
+		Service:   request.GetService(),
+		Instance:  request.GetInstance(),
+		Version:   request.GetVersion(),
+		Namespace: request.GetNamespace(),
+		Cluster:   request.GetCluster(),
+		Requester: ctx.requester(stream.Context(), request.GetRequester()),
+		Values:    request.GetValues(),
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+
+	return ctx.streamEvents(stream, application.Job, events)
+}
+
+func (ctx *Server) Upgrade(
+	request *deploymentv1.UpgradeRequest,
+	stream grpc.ServerStreamingServer[deploymentv1.DeploymentEvent],
+) error {
+	if leader, outgoing, err := ctx.forwarder.leader(stream.Context()); leader != nil || err != nil {
+		if err != nil {
+			return toStatus(err)
+		}
+
+		client, err := leader.Upgrade(outgoing, request)
+		if err != nil {
+			return err
+		}
+
+		return relay(client, stream)
+	}
+
+	events, unsubscribe := ctx.broker.Subscribe(models.EventFilter{})
+	defer unsubscribe()
+
+	application, err := ctx.manager.Upgrade(stream.Context(), models.DeploymentRequest{
+		Service:   request.GetService(),
+		Instance:  request.GetInstance(),
+		Version:   request.GetVersion(),
+		Requester: ctx.requester(stream.Context(), request.GetRequester()),
+		Values:    request.GetValues(),
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+
+	return ctx.streamEvents(stream, application.Job, events)
+}
+
+func (ctx *Server) Delete(
+	background context.Context,
+	request *deploymentv1.DeleteRequest,
+) (*deploymentv1.DeleteResponse, error) {
+	if leader, outgoing, err := ctx.forwarder.leader(background); leader != nil || err != nil {
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		return leader.Delete(outgoing, request)
+	}
+
+	if err := ctx.manager.Delete(background, models.DeploymentRequest{
+		Instance:  request.GetInstance(),
+		Requester: ctx.requester(background, ""),
+	}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &deploymentv1.DeleteResponse{}, nil
+}
+
+// streamEvents relays the events of the job until it ends. The broker drops
+// events for subscribers that fall behind, so the persisted job decides when
+// the stream ends.
+func (ctx *Server) streamEvents(
+	stream grpc.ServerStreamingServer[deploymentv1.DeploymentEvent],
+	id string,
+	events <-chan *models.SystemMessage,
+) error {
+	poll := time.NewTicker(jobPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-poll.C:
+			job, err := ctx.jobs.Get(id)
+			if err != nil {
+				return toStatus(err)
+			}
+
+			if job.Final() {
+				return stream.Send(toDeploymentEvent(job.Event()))
+			}
+			if !job.Deadline.IsZero() && time.Now().After(job.Deadline.Add(jobPollInterval)) {
+				return status.Errorf(codes.DeadlineExceeded, "job '%s' did not finish by %s", id, job.Deadline.Format(time.RFC3339))
+			}
+		case message, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+
+			event, ok := message.Value.(*models.StatusEvent)
+			if !ok || event.Job != id {
+				continue
+			}
+
+			if err := stream.Send(toDeploymentEvent(event)); err != nil {
+				return err
+			}
+
+			if event.Final() {
+				return nil
+			}
+		}
+	}
+}
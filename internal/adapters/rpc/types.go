@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"errors"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	deploymentv1 "tera/deployment/api/deployment/v1"
+	"tera/deployment/internal/domain/models"
+)
+
+func toApplication(application *models.Application) *deploymentv1.Application {
+	return &deploymentv1.Application{
+		Name:      application.Name,
+		Service:   application.Service,
+		Instance:  application.Instance,
+		Version:   application.Version,
+		Namespace: application.Namespace,
+		Cluster:   application.Cluster,
+		Status: &deploymentv1.ApplicationStatus{
+			Sync:   application.Status.Sync,
+			Health: application.Status.Health,
+		},
+	}
+}
+
+func toDeploymentEvent(event *models.StatusEvent) *deploymentv1.DeploymentEvent {
+	result := &deploymentv1.DeploymentEvent{
+		Type:      event.Type,
+		Service:   event.Service,
+		Instance:  event.Instance,
+		Namespace: event.Namespace,
+		Cluster:   event.Cluster,
+		Version:   event.Version,
+		Message:   event.Message,
+		Violations: lo.Map(event.Violations, func(item models.Violation, _ int) *deploymentv1.Violation {
+			return &deploymentv1.Violation{
+				Path:    item.Path,
+				Message: item.Message,
+			}
+		}),
+		QueueDepth:  int32(event.QueueDepth),
+		WaitSeconds: event.Waited,
+		Timestamp:   event.Time.Unix(),
+	}
+
+	if event.Status != nil {
+		result.Status = &deploymentv1.ApplicationStatus{
+			Sync:   event.Status.Sync,
+			Health: event.Status.Health,
+		}
+	}
+
+	return result
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, models.ErrServiceNotFound),
+		errors.Is(err, models.ErrApplicationNotFound),
+		errors.Is(err, models.ErrJobNotFound),
+		errors.Is(err, models.ErrApprovalNotFound),
+		errors.Is(err, models.ErrScheduleNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrApplicationExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, models.ErrServiceMismatch),
+		errors.Is(err, models.ErrDependencyMissing),
+		errors.Is(err, models.ErrDependentsDeployed),
+		errors.Is(err, models.ErrApprovalRequired),
+		errors.Is(err, models.ErrApprovalClosed),
+		errors.Is(err, models.ErrDeploymentFrozen),
+		errors.Is(err, models.ErrDeploymentDeferred),
+		errors.Is(err, models.ErrScheduleClosed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, models.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, models.ErrClusterNotAllowed),
+		errors.Is(err, models.ErrPermissionDenied),
+		errors.Is(err, models.ErrAdmissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, models.ErrOperationInProgress):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, models.ErrInvalidValues):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrNotLeader):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,417 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const defaultHistoryLimit = 100
+
+var (
+	historyBucket   = []byte("history")
+	jobBucket       = []byte("jobs")
+	activeJobBucket = []byte("active_jobs")
+	approvalBucket  = []byte("approvals")
+	deferralBucket  = []byte("deferrals")
+	scheduleBucket  = []byte("schedules")
+	nonceBucket     = []byte("nonces")
+)
+
+type Bolt struct {
+	db *bbolt.DB
+}
+
+func NewBoltStorage(conf *config.Config) ports.Storage {
+	if err := os.MkdirAll(filepath.Dir(conf.Storage.Path), 0o755); err != nil {
+		logger.Error("failed to create storage directory", zap.Error(err))
+
+		panic(err)
+	}
+
+	db, err := bbolt.Open(conf.Storage.Path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		logger.Error("failed to open storage", zap.String("path", conf.Storage.Path), zap.Error(err))
+
+		panic(err)
+	}
+
+	if err = db.Update(func(tx *bbolt.Tx) error {
+		indexed := tx.Bucket(activeJobBucket) != nil
+
+		for _, bucket := range [][]byte{historyBucket, jobBucket, activeJobBucket, approvalBucket, deferralBucket, scheduleBucket, nonceBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		if indexed {
+			return nil
+		}
+
+		return tx.Bucket(jobBucket).ForEach(func(key, value []byte) error {
+			var job models.Job
+			if err := json.Unmarshal(value, &job); err != nil || job.Final() {
+				return nil
+			}
+
+			return tx.Bucket(activeJobBucket).Put(key, nil)
+		})
+	}); err != nil {
+		logger.Error("failed to initialize storage", zap.Error(err))
+
+		panic(err)
+	}
+
+	return &Bolt{
+		db: db,
+	}
+}
+
+func (ctx *Bolt) AppendHistory(record models.HistoryRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal history record")
+	}
+
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(sequenceKey(sequence), data)
+	})
+}
+
+func (ctx *Bolt) QueryHistory(query models.HistoryQuery) ([]models.HistoryRecord, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	records := make([]models.HistoryRecord, 0)
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(historyBucket).Cursor()
+
+		for key, value := cursor.Last(); key != nil && len(records) < limit; key, value = cursor.Prev() {
+			var record models.HistoryRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return errors.Wrap(err, "failed to unmarshal history record")
+			}
+
+			if !query.From.IsZero() && record.Time.Before(query.From) {
+				break
+			}
+
+			if query.Match(record) {
+				records = append(records, record)
+			}
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+func (ctx *Bolt) SaveJob(job models.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal job")
+	}
+
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(jobBucket).Put([]byte(job.ID), data); err != nil {
+			return err
+		}
+
+		if job.Final() {
+			return tx.Bucket(activeJobBucket).Delete([]byte(job.ID))
+		}
+
+		return tx.Bucket(activeJobBucket).Put([]byte(job.ID), nil)
+	})
+}
+
+func (ctx *Bolt) GetJob(id string) (*models.Job, error) {
+	var job *models.Job
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(jobBucket).Get([]byte(id))
+		if data == nil {
+			return models.NewError(models.ErrJobNotFound, "job '%s' not found", id)
+		}
+
+		job = &models.Job{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return errors.Wrap(err, "failed to unmarshal job")
+		}
+
+		return nil
+	})
+
+	return job, err
+}
+
+func (ctx *Bolt) ListActiveJobs() ([]models.Job, error) {
+	jobs := make([]models.Job, 0)
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(jobBucket)
+
+		return tx.Bucket(activeJobBucket).ForEach(func(key, _ []byte) error {
+			var job models.Job
+			if err := json.Unmarshal(bucket.Get(key), &job); err != nil {
+				return errors.Wrap(err, "failed to unmarshal job")
+			}
+
+			if !job.Final() {
+				jobs = append(jobs, job)
+			}
+
+			return nil
+		})
+	})
+
+	return jobs, err
+}
+
+func (ctx *Bolt) SaveApproval(approval models.Approval) error {
+	data, err := json.Marshal(approval)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal approval")
+	}
+
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(approvalBucket).Put([]byte(approval.ID), data)
+	})
+}
+
+func (ctx *Bolt) GetApproval(id string) (*models.Approval, error) {
+	var approval *models.Approval
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(approvalBucket).Get([]byte(id))
+		if data == nil {
+			return models.NewError(models.ErrApprovalNotFound, "approval '%s' not found", id)
+		}
+
+		approval = &models.Approval{}
+		if err := json.Unmarshal(data, approval); err != nil {
+			return errors.Wrap(err, "failed to unmarshal approval")
+		}
+
+		return nil
+	})
+
+	return approval, err
+}
+
+func (ctx *Bolt) ListApprovals() ([]models.Approval, error) {
+	approvals := make([]models.Approval, 0)
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(approvalBucket).ForEach(func(_, value []byte) error {
+			var approval models.Approval
+			if err := json.Unmarshal(value, &approval); err != nil {
+				return errors.Wrap(err, "failed to unmarshal approval")
+			}
+
+			approvals = append(approvals, approval)
+
+			return nil
+		})
+	})
+
+	return approvals, err
+}
+
+func (ctx *Bolt) SaveDeferral(deferral models.Deferral) error {
+	data, err := json.Marshal(deferral)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal deferral")
+	}
+
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deferralBucket).Put([]byte(deferral.ID), data)
+	})
+}
+
+func (ctx *Bolt) ListDeferrals() ([]models.Deferral, error) {
+	deferrals := make([]models.Deferral, 0)
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deferralBucket).ForEach(func(_, value []byte) error {
+			var deferral models.Deferral
+			if err := json.Unmarshal(value, &deferral); err != nil {
+				return errors.Wrap(err, "failed to unmarshal deferral")
+			}
+
+			deferrals = append(deferrals, deferral)
+
+			return nil
+		})
+	})
+
+	return deferrals, err
+}
+
+func (ctx *Bolt) DeleteDeferral(id string) error {
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deferralBucket).Delete([]byte(id))
+	})
+}
+
+func (ctx *Bolt) SaveSchedule(schedule models.Schedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal schedule")
+	}
+
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(scheduleBucket).Put([]byte(schedule.ID), data)
+	})
+}
+
+func (ctx *Bolt) GetSchedule(id string) (*models.Schedule, error) {
+	var schedule *models.Schedule
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(scheduleBucket).Get([]byte(id))
+		if data == nil {
+			return models.NewError(models.ErrScheduleNotFound, "schedule '%s' not found", id)
+		}
+
+		schedule = &models.Schedule{}
+		if err := json.Unmarshal(data, schedule); err != nil {
+			return errors.Wrap(err, "failed to unmarshal schedule")
+		}
+
+		return nil
+	})
+
+	return schedule, err
+}
+
+func (ctx *Bolt) ListSchedules() ([]models.Schedule, error) {
+	schedules := make([]models.Schedule, 0)
+
+	err := ctx.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(scheduleBucket).ForEach(func(_, value []byte) error {
+			var schedule models.Schedule
+			if err := json.Unmarshal(value, &schedule); err != nil {
+				return errors.Wrap(err, "failed to unmarshal schedule")
+			}
+
+			schedules = append(schedules, schedule)
+
+			return nil
+		})
+	})
+
+	return schedules, err
+}
+
+func (ctx *Bolt) ClaimNonce(nonce string, expires time.Time) (bool, error) {
+	claimed := false
+	err := ctx.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(nonceBucket)
+		if value := bucket.Get([]byte(nonce)); len(value) == 8 && time.Now().UnixNano() < int64(binary.BigEndian.Uint64(value)) {
+			return nil
+		}
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(expires.UnixNano()))
+		claimed = true
+
+		return bucket.Put([]byte(nonce), value)
+	})
+
+	return claimed, err
+}
+
+// Prune drops history records and finished jobs older than before, and nonces
+// that have expired. History is appended in time order, so its scan stops at the
+// first newer record.
+func (ctx *Bolt) Prune(before time.Time) error {
+	return ctx.db.Update(func(tx *bbolt.Tx) error {
+		now := time.Now().UnixNano()
+		var claimed [][]byte
+		nonces := tx.Bucket(nonceBucket)
+		if err := nonces.ForEach(func(key, value []byte) error {
+			if len(value) != 8 || int64(binary.BigEndian.Uint64(value)) <= now {
+				claimed = append(claimed, key)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, key := range claimed {
+			if err := nonces.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		cursor := tx.Bucket(historyBucket).Cursor()
+		for key, value := cursor.First(); key != nil; key, value = cursor.First() {
+			var record models.HistoryRecord
+			if err := json.Unmarshal(value, &record); err == nil && !record.Time.Before(before) {
+				break
+			}
+
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+
+		var expired [][]byte
+		bucket := tx.Bucket(jobBucket)
+		if err := bucket.ForEach(func(key, value []byte) error {
+			var job models.Job
+			if err := json.Unmarshal(value, &job); err != nil || (job.Final() && job.UpdatedAt.Before(before)) {
+				expired = append(expired, key)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+			if err := tx.Bucket(activeJobBucket).Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (ctx *Bolt) Close() error {
+	return ctx.db.Close()
+}
+
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+
+	return key
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AdmissionError struct {
+	Instance   string
+	Violations []Violation
+}
+
+func (err *AdmissionError) Error() string {
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", violation.Path, violation.Message))
+	}
+
+	return fmt.Sprintf("deployment of '%s' was denied by admission policies: %s", err.Instance, strings.Join(messages, "; "))
+}
+
+func (err *AdmissionError) Unwrap() error {
+	return ErrAdmissionDenied
+}
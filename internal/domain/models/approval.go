@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"time"
+)
+
+const (
+	ApprovalStatePending   = "pending"
+	ApprovalStateApproved  = "approved"
+	ApprovalStateRejected  = "rejected"
+	ApprovalStateExpired   = "expired"
+	ApprovalStateDeferred  = "deferred"
+	ApprovalStateExecuting = "executing"
+	ApprovalStateExecuted  = "executed"
+	ApprovalStateFailed    = "failed"
+)
+
+type Approval struct {
+	ID          string            `json:"id"`
+	Action      string            `json:"action"`
+	State       string            `json:"state"`
+	Request     DeploymentRequest `json:"request"`
+	FromVersion string            `json:"from_version,omitempty"`
+	Required    int               `json:"required"`
+	Approvers   []ApprovalVote    `json:"approvers,omitempty"`
+	Rejecter    *ApprovalVote     `json:"rejecter,omitempty"`
+	Job         string            `json:"job,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+type ApprovalVote struct {
+	Requester Identity  `json:"requester"`
+	Comment   string    `json:"comment,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type ApprovalPendingError struct {
+	Approval *Approval
+}
+
+func (approval *Approval) Final() bool {
+	return !lo.Contains(
+		[]string{ApprovalStatePending, ApprovalStateApproved, ApprovalStateDeferred, ApprovalStateExecuting},
+		approval.State,
+	)
+}
+
+// Runnable reports whether the approved request may still run, either right
+// after the vote or once the freeze window that deferred it closes.
+func (approval *Approval) Runnable() bool {
+	return approval.State == ApprovalStateApproved || approval.State == ApprovalStateDeferred
+}
+
+func (approval *Approval) Command() *KafkaMessage {
+	return &KafkaMessage{
+		Action:    approval.Action,
+		Approval:  approval.ID,
+		Service:   approval.Request.Service,
+		Instance:  approval.Request.Instance,
+		Version:   approval.Request.Version,
+		Namespace: approval.Request.Namespace,
+		Cluster:   approval.Request.Cluster,
+		Requester: approval.Request.Requester,
+		Values:    approval.Request.Values,
+		Replace:   approval.Request.Replace,
+		Internal:  true,
+	}
+}
+
+func (approval *Approval) Expired(now time.Time) bool {
+	return approval.State == ApprovalStatePending && now.After(approval.ExpiresAt)
+}
+
+func (err *ApprovalPendingError) Error() string {
+	return fmt.Sprintf(
+		"%s of '%s' requires %d approval(s), pending as approval '%s'",
+		err.Approval.Action,
+		err.Approval.Request.Instance,
+		err.Approval.Required,
+		err.Approval.ID,
+	)
+}
+
+func (err *ApprovalPendingError) Unwrap() error {
+	return ErrApprovalRequired
+}
@@ -1,11 +1,88 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultCluster       = "in-cluster"
+	DefaultClusterServer = "https://kubernetes.default.svc"
+)
+
+const (
+	SyncStatusSynced    = "Synced"
+	HealthStatusHealthy = "Healthy"
+)
+
+const (
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	LabelService   = "deployment.tera.io/service"
+	LabelInstance  = "deployment.tera.io/instance"
+	LabelNamespace = "deployment.tera.io/namespace"
+	LabelVersion   = "deployment.tera.io/version"
+
+	ManagedBy = "tera-deployment-server"
+)
+
+const (
+	AnnotationRequestedBy = "deployment.tera.io/requested-by"
+	AnnotationTeam        = "deployment.tera.io/team"
+	AnnotationOrigin      = "deployment.tera.io/origin"
+	AnnotationJob         = "deployment.tera.io/job"
+)
+
 type Application struct {
-	Name    string
-	Version string
+	Name      string
+	Service   string
+	Instance  string
+	Version   string
+	Namespace string
+	Cluster   string
+	Values    map[string]string
+	Status    ApplicationStatus
+	Job       string
+}
+
+func InstanceName(service, namespace string) string {
+	if namespace == "" || namespace == service {
+		return service
+	}
+
+	return fmt.Sprintf("%s-%s", service, namespace)
 }
 
 type ApplicationStatus struct {
-	Sync   bool
-	Health bool
+	Sync         string    `json:"sync"`
+	Health       string    `json:"health"`
+	Revision     string    `json:"revision,omitempty"`
+	ReconciledAt time.Time `json:"reconciled_at,omitempty"`
+}
+
+func (status ApplicationStatus) Ready() bool {
+	return status.Sync == SyncStatusSynced && status.Health == HealthStatusHealthy
+}
+
+func (status ApplicationStatus) Deployed(version string, since time.Time) bool {
+	return status.Ready() &&
+		(version == "" || status.Revision == version) &&
+		!status.ReconciledAt.Before(since.Truncate(time.Second))
+}
+
+type Revision struct {
+	ID         int64
+	Version    string
+	Values     map[string]string
+	DeployedAt time.Time
+}
+
+type DependencyGraph struct {
+	Nodes []DependencyNode
+}
+
+type DependencyNode struct {
+	Service   string
+	Version   string
+	Depends   []Application
+	Instances []Application
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditResultSucceeded = "succeeded"
+	AuditResultFailed    = "failed"
+	AuditResultDenied    = "denied"
+)
+
+type AuditEntry struct {
+	Sequence  uint64    `json:"sequence"`
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Requester Identity  `json:"requester"`
+	Job       string    `json:"job,omitempty"`
+	Service   string    `json:"service"`
+	Instance  string    `json:"instance"`
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster"`
+	Version   string    `json:"version"`
+	Result    string    `json:"result"`
+	Message   string    `json:"message,omitempty"`
+	Previous  string    `json:"previous"`
+	Hash      string    `json:"hash"`
+}
+
+type AuditVerification struct {
+	Valid   bool
+	Entries int
+	Head    string
+	Error   string
+}
+
+func (entry AuditEntry) Digest(key []byte) string {
+	entry.Hash = ""
+
+	data, _ := json.Marshal(entry)
+	if len(key) == 0 {
+		sum := sha256.Sum256(data)
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
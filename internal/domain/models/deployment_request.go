@@ -0,0 +1,30 @@
+package models
+
+import "maps"
+
+type DeploymentRequest struct {
+	Job       string            `json:"job,omitempty"`
+	Approval  string            `json:"approval,omitempty"`
+	Schedule  string            `json:"schedule,omitempty"`
+	Service   string            `json:"service"`
+	Instance  string            `json:"instance"`
+	Version   string            `json:"version"`
+	Namespace string            `json:"namespace"`
+	Cluster   string            `json:"cluster"`
+	Requester Identity          `json:"requester"`
+	Values    map[string]string `json:"values,omitempty"`
+	Replace   bool              `json:"replace,omitempty"`
+	Override  bool              `json:"override,omitempty"`
+}
+
+// Same reports whether both requests deploy the same chart with the same
+// values to the same place, whoever asked for it.
+func (request *DeploymentRequest) Same(other DeploymentRequest) bool {
+	return request.Instance == other.Instance &&
+		request.Service == other.Service &&
+		request.Version == other.Version &&
+		request.Namespace == other.Namespace &&
+		request.Cluster == other.Cluster &&
+		request.Replace == other.Replace &&
+		maps.Equal(request.Values, other.Values)
+}
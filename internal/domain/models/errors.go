@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrServiceNotFound     = errors.New("service not found")
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrApplicationExists   = errors.New("application already exists")
+	ErrServiceMismatch     = errors.New("service mismatch")
+	ErrClusterNotAllowed   = errors.New("cluster not allowed")
+	ErrDependencyMissing   = errors.New("dependency missing")
+	ErrDependentsDeployed  = errors.New("dependents deployed")
+	ErrInvalidValues       = errors.New("invalid values")
+	ErrJobNotFound         = errors.New("job not found")
+	ErrOperationInProgress = errors.New("operation in progress")
+	ErrUnauthenticated     = errors.New("unauthenticated")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrAdmissionDenied     = errors.New("admission denied")
+	ErrApprovalRequired    = errors.New("approval required")
+	ErrApprovalNotFound    = errors.New("approval not found")
+	ErrApprovalClosed      = errors.New("approval closed")
+	ErrDeploymentFrozen    = errors.New("deployment frozen")
+	ErrDeploymentDeferred  = errors.New("deployment deferred")
+	ErrScheduleNotFound    = errors.New("schedule not found")
+	ErrScheduleClosed      = errors.New("schedule closed")
+	ErrNotLeader           = errors.New("not the leader")
+)
+
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func NewError(kind error, format string, args ...any) error {
+	return &Error{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+func (err *Error) Unwrap() error {
+	return err.Kind
+}
@@ -0,0 +1,23 @@
+package models
+
+import "github.com/samber/lo"
+
+type EventFilter struct {
+	Services   []string
+	Namespaces []string
+	Types      []string
+	Replay     bool
+}
+
+func (filter EventFilter) Match(message *SystemMessage) bool {
+	event, ok := message.Value.(*StatusEvent)
+	if !ok {
+		return len(filter.Services) == 0 &&
+			len(filter.Namespaces) == 0 &&
+			(len(filter.Types) == 0 || lo.Contains(filter.Types, message.Key.Value))
+	}
+
+	return (len(filter.Services) == 0 || lo.Contains(filter.Services, event.Service)) &&
+		(len(filter.Namespaces) == 0 || lo.Contains(filter.Namespaces, event.Namespace)) &&
+		(len(filter.Types) == 0 || lo.Contains(filter.Types, event.Type))
+}
@@ -1,11 +1,38 @@
 package models
 
+import (
+	"context"
+	"time"
+)
+
 type KafkaMessage struct {
-	Action    string            `json:"action"` // create, delete
+	Action    string            `json:"action"` // fetch, create, upgrade, rollback, delete, sync, graph, adopt, history, job, plan, approve, reject, approvals, schedules, cancel
+	Job       string            `json:"job"`
+	Approval  string            `json:"approval"`
+	Schedule  string            `json:"schedule"`
+	Comment   string            `json:"comment"`
 	Service   string            `json:"service"`
+	Instance  string            `json:"instance"`
 	Version   string            `json:"version"`
 	Namespace string            `json:"namespace"`
+	Cluster   string            `json:"cluster"`
+	Requester Identity          `json:"requester"`
 	Values    map[string]string `json:"values"`
+	Replace   bool              `json:"replace"`
+	Revision  int64             `json:"revision"`
+	Result    string            `json:"result"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Limit     int               `json:"limit"`
+	Override  bool              `json:"override"`
+	ExecuteAt time.Time         `json:"execute_at"`
+	Cron      string            `json:"cron"`
+	Timezone  string            `json:"timezone"`
+
+	Context context.Context `json:"-"`
+	// Internal marks commands the service re-injects itself, such as released
+	// deferrals and due schedules; only those may carry an approval to execute.
+	Internal bool `json:"-"`
 }
 
 type SystemMessage struct {
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FreezeModeReject = "reject"
+	FreezeModeQueue  = "queue"
+)
+
+const AnnotationSyncWindows = "deployment.tera.io/sync-windows"
+
+type FreezeWindow struct {
+	Name    string    `json:"name"`
+	Mode    string    `json:"mode"`
+	Message string    `json:"message,omitempty"`
+	Active  bool      `json:"active"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+type SyncWindow struct {
+	Schedule     string
+	Duration     time.Duration
+	Timezone     string
+	Applications []string
+	Namespaces   []string
+	Clusters     []string
+}
+
+type Deferral struct {
+	ID        string            `json:"id"`
+	Action    string            `json:"action"`
+	Request   DeploymentRequest `json:"request"`
+	Revision  int64             `json:"revision,omitempty"`
+	Window    string            `json:"window"`
+	ReleaseAt time.Time         `json:"release_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type FreezeError struct {
+	Window FreezeWindow
+}
+
+type DeferredError struct {
+	Deferral *Deferral
+}
+
+func (deferral *Deferral) Command() *KafkaMessage {
+	return &KafkaMessage{
+		Action:    deferral.Action,
+		Approval:  deferral.Request.Approval,
+		Schedule:  deferral.Request.Schedule,
+		Service:   deferral.Request.Service,
+		Instance:  deferral.Request.Instance,
+		Version:   deferral.Request.Version,
+		Namespace: deferral.Request.Namespace,
+		Cluster:   deferral.Request.Cluster,
+		Requester: deferral.Request.Requester,
+		Values:    deferral.Request.Values,
+		Replace:   deferral.Request.Replace,
+		Revision:  deferral.Revision,
+		Internal:  true,
+	}
+}
+
+func (err *FreezeError) Error() string {
+	message := fmt.Sprintf("deployments are frozen by window '%s' until %s", err.Window.Name, err.Window.End.Format(time.RFC3339))
+	if err.Window.Message != "" {
+		message += ": " + err.Window.Message
+	}
+
+	return message
+}
+
+func (err *FreezeError) Unwrap() error {
+	return ErrDeploymentFrozen
+}
+
+func (err *DeferredError) Error() string {
+	return fmt.Sprintf(
+		"%s of '%s' is deferred by freeze window '%s' until %s",
+		err.Deferral.Action,
+		err.Deferral.Request.Instance,
+		err.Deferral.Window,
+		err.Deferral.ReleaseAt.Format(time.RFC3339),
+	)
+}
+
+func (err *DeferredError) Unwrap() error {
+	return ErrDeploymentDeferred
+}
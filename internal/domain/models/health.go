@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type HealthCheck struct {
+	Name      string
+	Healthy   bool
+	Message   string
+	CheckedAt time.Time
+}
+
+type HealthReport struct {
+	Healthy bool
+	Checks  []HealthCheck
+}
+
+func NewHealthCheck(name string, err error) HealthCheck {
+	check := HealthCheck{
+		Name:      name,
+		Healthy:   err == nil,
+		Message:   "ok",
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		check.Message = err.Error()
+	}
+
+	return check
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	HistoryKindCommand   = "command"
+	HistoryKindOperation = "operation"
+	HistoryKindStatus    = "status"
+)
+
+const (
+	HistoryResultReceived = "received"
+	HistoryResultAccepted = "accepted"
+	HistoryResultFailed   = "failed"
+)
+
+type HistoryRecord struct {
+	Time      time.Time          `json:"time"`
+	Kind      string             `json:"kind"`
+	Action    string             `json:"action"`
+	Requester Identity           `json:"requester"`
+	Service   string             `json:"service"`
+	Instance  string             `json:"instance"`
+	Namespace string             `json:"namespace"`
+	Cluster   string             `json:"cluster"`
+	Version   string             `json:"version"`
+	Result    string             `json:"result"`
+	Message   string             `json:"message,omitempty"`
+	Status    *ApplicationStatus `json:"status,omitempty"`
+}
+
+type HistoryQuery struct {
+	Service   string
+	Namespace string
+	Result    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+func (query HistoryQuery) Match(record HistoryRecord) bool {
+	return (query.Service == "" || query.Service == record.Service) &&
+		(query.Namespace == "" || query.Namespace == record.Namespace) &&
+		(query.Result == "" || query.Result == record.Result) &&
+		(query.From.IsZero() || !record.Time.Before(query.From)) &&
+		(query.To.IsZero() || !record.Time.After(query.To))
+}
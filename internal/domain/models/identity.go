@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	SystemKafka      = "kafka"
+	SystemREST       = "rest"
+	SystemGRPC       = "grpc"
+	SystemReconciler = "reconciler"
+)
+
+type requesterKey struct{}
+
+type Identity struct {
+	User   string `json:"user,omitempty"`
+	Team   string `json:"team,omitempty"`
+	System string `json:"system,omitempty"`
+}
+
+func WithRequester(background context.Context, identity Identity) context.Context {
+	return context.WithValue(background, requesterKey{}, identity)
+}
+
+func RequesterFrom(background context.Context) Identity {
+	identity, _ := background.Value(requesterKey{}).(Identity)
+
+	return identity
+}
+
+// UnmarshalJSON also accepts a plain string, which older clients and stored records use for the user.
+func (identity *Identity) UnmarshalJSON(data []byte) error {
+	var user string
+	if err := json.Unmarshal(data, &user); err == nil {
+		*identity = Identity{User: user}
+
+		return nil
+	}
+
+	type plain Identity
+
+	return json.Unmarshal(data, (*plain)(identity))
+}
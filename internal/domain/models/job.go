@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/samber/lo"
+	"time"
+)
+
+const (
+	JobStatePending        = "pending"
+	JobStateRunning        = "running"
+	JobStateWaitingForSync = "waiting_for_sync"
+	JobStateSucceeded      = "succeeded"
+	JobStateFailed         = "failed"
+	JobStateTimedOut       = "timed_out"
+)
+
+type Job struct {
+	ID        string             `json:"id"`
+	Action    string             `json:"action"`
+	State     string             `json:"state"`
+	Service   string             `json:"service"`
+	Instance  string             `json:"instance"`
+	Namespace string             `json:"namespace"`
+	Cluster   string             `json:"cluster"`
+	Version   string             `json:"version"`
+	Requester Identity           `json:"requester"`
+	Message   string             `json:"message,omitempty"`
+	Status    *ApplicationStatus `json:"status,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Deadline  time.Time          `json:"deadline,omitempty"`
+}
+
+func (job *Job) Final() bool {
+	return lo.Contains([]string{JobStateSucceeded, JobStateFailed, JobStateTimedOut}, job.State)
+}
+
+// Event describes the final state of the job the way the tracker announced it.
+func (job *Job) Event() *StatusEvent {
+	eventType := lo.Ternary(job.State == JobStateSucceeded, StatusEventSucceeded, StatusEventFailed)
+	event := NewStatusEvent(eventType, job.Request(), job.Message)
+	event.Status = job.Status
+
+	return event
+}
+
+func (job *Job) Request() DeploymentRequest {
+	return DeploymentRequest{
+		Job:       job.ID,
+		Service:   job.Service,
+		Instance:  job.Instance,
+		Version:   job.Version,
+		Namespace: job.Namespace,
+		Cluster:   job.Cluster,
+		Requester: job.Requester,
+	}
+}
@@ -7,9 +7,17 @@ type Key struct {
 var (
 	ArgocdApplicationList   Key = Key{Value: "argocd_application_list"}
 	ArgocdApplicationStatus Key = Key{Value: "argocd_application_status"}
+	ArgocdApplicationGraph  Key = Key{Value: "argocd_application_graph"}
+	DeploymentHistory       Key = Key{Value: "deployment_history"}
+	DeploymentJob           Key = Key{Value: "deployment_job"}
+	DeploymentApproval      Key = Key{Value: "deployment_approval"}
+	DeploymentSchedule      Key = Key{Value: "deployment_schedule"}
+	ReconcilePlan           Key = Key{Value: "reconcile_plan"}
+	SecurityViolation       Key = Key{Value: "security_violation"}
 )
 
 var (
 	FetchArgocdApplication      = Key{Value: "fetch_argocd_application"}
 	CreateArgocdApplication Key = Key{Value: "create_argocd_application"}
+	AdoptArgocdApplication  Key = Key{Value: "adopt_argocd_application"}
 )
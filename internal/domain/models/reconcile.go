@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	PlanActionCreate  = "create"
+	PlanActionUpgrade = "upgrade"
+	PlanActionPrune   = "prune"
+)
+
+type DesiredApplication struct {
+	Service   string            `json:"service" yaml:"service"`
+	Instance  string            `json:"instance" yaml:"instance"`
+	Version   string            `json:"version" yaml:"version"`
+	Namespace string            `json:"namespace" yaml:"namespace"`
+	Cluster   string            `json:"cluster" yaml:"cluster"`
+	Values    map[string]string `json:"values,omitempty" yaml:"values"`
+}
+
+type PlanStep struct {
+	Action      string `json:"action"`
+	Service     string `json:"service"`
+	Instance    string `json:"instance"`
+	Namespace   string `json:"namespace"`
+	Cluster     string `json:"cluster"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type Plan struct {
+	Applied bool       `json:"applied"`
+	Steps   []PlanStep `json:"steps"`
+	Time    time.Time  `json:"time"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	ScheduleStateActive    = "active"
+	ScheduleStateCompleted = "completed"
+	ScheduleStateCancelled = "cancelled"
+)
+
+type Schedule struct {
+	ID        string       `json:"id"`
+	State     string       `json:"state"`
+	Command   KafkaMessage `json:"command"`
+	ExecuteAt time.Time    `json:"execute_at"`
+	Cron      string       `json:"cron,omitempty"`
+	Timezone  string       `json:"timezone,omitempty"`
+	NextRun   time.Time    `json:"next_run"`
+	LastRun   time.Time    `json:"last_run"`
+	Runs      int          `json:"runs"`
+	Message   string       `json:"message,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func (schedule *Schedule) Recurring() bool {
+	return schedule.Cron != ""
+}
+
+func (schedule *Schedule) Request() DeploymentRequest {
+	return DeploymentRequest{
+		Schedule:  schedule.ID,
+		Service:   schedule.Command.Service,
+		Instance:  schedule.Command.Instance,
+		Version:   schedule.Command.Version,
+		Namespace: schedule.Command.Namespace,
+		Cluster:   schedule.Command.Cluster,
+		Requester: schedule.Command.Requester,
+	}
+}
+
+func (schedule *Schedule) Execution() *KafkaMessage {
+	command := schedule.Command
+	command.Schedule = schedule.ID
+	command.Internal = true
+
+	return &command
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	SecurityReasonUnsigned  = "unsigned"
+	SecurityReasonUnknownID = "unknown_key"
+	SecurityReasonInvalid   = "invalid_signature"
+	SecurityReasonStale     = "stale_timestamp"
+	SecurityReasonReplayed  = "replayed_nonce"
+	SecurityReasonPrincipal = "principal_mismatch"
+)
+
+type SecurityEvent struct {
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	KeyID     string    `json:"key_id,omitempty"`
+	Topic     string    `json:"topic"`
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Time      time.Time `json:"time"`
+}
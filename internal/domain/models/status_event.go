@@ -0,0 +1,75 @@
+package models
+
+import (
+	"github.com/samber/lo"
+	"time"
+)
+
+const (
+	StatusEventRejected     = "rejected"
+	StatusEventPending      = "pending_approval"
+	StatusEventApproved     = "approved"
+	StatusEventDenied       = "approval_rejected"
+	StatusEventExpired      = "approval_expired"
+	StatusEventDeferred     = "deferred"
+	StatusEventOverridden   = "freeze_overridden"
+	StatusEventScheduled    = "scheduled"
+	StatusEventTriggered    = "schedule_triggered"
+	StatusEventCancelled    = "schedule_cancelled"
+	StatusEventSyncing      = "sync_requested"
+	StatusEventQueued       = "queued"
+	StatusEventStarted      = "started"
+	StatusEventDependencies = "dependencies_checked"
+	StatusEventCreated      = "created"
+	StatusEventUpgraded     = "upgraded"
+	StatusEventDeleted      = "deleted"
+	StatusEventAdopted      = "adopted"
+	StatusEventProgress     = "progress"
+	StatusEventSucceeded    = "succeeded"
+	StatusEventFailed       = "failed"
+)
+
+type StatusEvent struct {
+	Type       string             `json:"type"`
+	Job        string             `json:"job,omitempty"`
+	Approval   string             `json:"approval,omitempty"`
+	Schedule   string             `json:"schedule,omitempty"`
+	Service    string             `json:"service"`
+	Instance   string             `json:"instance"`
+	Namespace  string             `json:"namespace"`
+	Cluster    string             `json:"cluster"`
+	Version    string             `json:"version"`
+	Message    string             `json:"message,omitempty"`
+	Status     *ApplicationStatus `json:"status,omitempty"`
+	Violations []Violation        `json:"violations,omitempty"`
+	QueueDepth int                `json:"queue_depth,omitempty"`
+	Waited     float64            `json:"wait_seconds,omitempty"`
+	Time       time.Time          `json:"time"`
+}
+
+func NewStatusEvent(eventType string, request DeploymentRequest, message string) *StatusEvent {
+	return &StatusEvent{
+		Type:      eventType,
+		Job:       request.Job,
+		Approval:  request.Approval,
+		Schedule:  request.Schedule,
+		Service:   request.Service,
+		Instance:  request.Instance,
+		Namespace: request.Namespace,
+		Cluster:   request.Cluster,
+		Version:   request.Version,
+		Message:   message,
+		Time:      time.Now(),
+	}
+}
+
+func (event *StatusEvent) Final() bool {
+	return lo.Contains([]string{
+		StatusEventRejected,
+		StatusEventSucceeded,
+		StatusEventFailed,
+		StatusEventDeleted,
+		StatusEventDenied,
+		StatusEventExpired,
+	}, event.Type)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Service    string
+	Violations []Violation
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", violation.Path, violation.Message))
+	}
+
+	return fmt.Sprintf("values of service '%s' are invalid: %s", err.Service, strings.Join(messages, "; "))
+}
+
+func (err *ValidationError) Unwrap() error {
+	return ErrInvalidValues
+}
+
+func ViolationsOf(err error) []Violation {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Violations
+	}
+
+	var admissionErr *AdmissionError
+	if errors.As(err, &admissionErr) {
+		return admissionErr.Violations
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+)
+
+type admissionPolicy struct {
+	name    string
+	message string
+	program cel.Program
+}
+
+type AdmissionController struct {
+	policies []admissionPolicy
+}
+
+func NewAdmissionController(conf *config.Config) usecases.AdmissionController {
+	env, err := cel.NewEnv(
+		cel.Variable("action", cel.StringType),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("current", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("deployed", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		ext.Strings(),
+	)
+	if err != nil {
+		logger.Error("failed to create admission environment", zap.Error(err))
+
+		panic(err)
+	}
+
+	policies := lo.Map(conf.Admission.Policies, func(item config.AdmissionPolicyConfig, _ int) admissionPolicy {
+		ast, issues := env.Compile(item.Expression)
+		if issues != nil && issues.Err() != nil {
+			logger.Error("failed to compile admission policy", zap.String("policy", item.Name), zap.Error(issues.Err()))
+
+			panic(issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			logger.Error("admission policy must evaluate to a bool", zap.String("policy", item.Name))
+
+			panic(fmt.Sprintf("admission policy '%s' returns %s, not bool", item.Name, ast.OutputType()))
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			logger.Error("failed to build admission policy", zap.String("policy", item.Name), zap.Error(err))
+
+			panic(err)
+		}
+
+		return admissionPolicy{
+			name:    item.Name,
+			message: lo.CoalesceOrEmpty(item.Message, fmt.Sprintf("violates %s", item.Expression)),
+			program: program,
+		}
+	})
+
+	return &AdmissionController{
+		policies: policies,
+	}
+}
+
+// Admit evaluates the policies for creates, upgrades and rollbacks alike; current
+// is the application being changed and is empty in the policies on create.
+func (ctx *AdmissionController) Admit(
+	action string,
+	request models.DeploymentRequest,
+	current *models.Application,
+	deployed []models.Application,
+) error {
+	if len(ctx.policies) == 0 {
+		return nil
+	}
+
+	variables := map[string]any{
+		"action": action,
+		"request": map[string]any{
+			"service":   request.Service,
+			"instance":  request.Instance,
+			"version":   request.Version,
+			"namespace": request.Namespace,
+			"cluster":   request.Cluster,
+			"values":    lo.Assign(request.Values),
+			"requester": map[string]any{
+				"user":   request.Requester.User,
+				"team":   request.Requester.Team,
+				"system": request.Requester.System,
+			},
+		},
+		"current": map[string]any{},
+		"deployed": lo.Map(deployed, func(item models.Application, _ int) map[string]any {
+			return admissionApplication(item)
+		}),
+	}
+	if current != nil {
+		variables["current"] = admissionApplication(*current)
+	}
+
+	violations := lo.FilterMap(ctx.policies, func(policy admissionPolicy, _ int) (models.Violation, bool) {
+		result, _, err := policy.program.Eval(variables)
+		if err != nil {
+			return models.Violation{Path: policy.name, Message: fmt.Sprintf("evaluation failed: %v", err)}, true
+		}
+
+		allowed, ok := result.Value().(bool)
+
+		return models.Violation{Path: policy.name, Message: policy.message}, !ok || !allowed
+	})
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &models.AdmissionError{
+		Instance:   request.Instance,
+		Violations: violations,
+	}
+}
+
+func admissionApplication(application models.Application) map[string]any {
+	return map[string]any{
+		"service":   application.Service,
+		"instance":  application.Instance,
+		"version":   application.Version,
+		"namespace": application.Namespace,
+		"cluster":   application.Cluster,
+		"values":    lo.Assign(application.Values),
+		"sync":      application.Status.Sync,
+		"health":    application.Status.Health,
+	}
+}
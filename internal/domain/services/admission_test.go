@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+)
+
+func TestAdmissionControllerAdmit(t *testing.T) {
+	admission := NewAdmissionController(&config.Config{
+		Admission: config.AdmissionConfig{
+			Policies: []config.AdmissionPolicyConfig{
+				{Name: "release-versions", Expression: `!request.namespace.startsWith("prod") || !request.version.contains("-")`},
+				{Name: "no-prod-rollbacks", Expression: `action != "rollback" || !request.namespace.startsWith("prod")`},
+				{Name: "same-major", Expression: `size(current) == 0 || current.version.split(".")[0] == request.version.split(".")[0]`},
+			},
+		},
+	})
+	current := &models.Application{Instance: "api", Version: "1.2.0", Namespace: "prod"}
+	request := func(version string) models.DeploymentRequest {
+		return models.DeploymentRequest{Instance: "api", Version: version, Namespace: "prod"}
+	}
+
+	tests := []struct {
+		name       string
+		action     string
+		request    models.DeploymentRequest
+		current    *models.Application
+		violations []string
+	}{
+		{name: "create", action: "create", request: request("2.0.0")},
+		{name: "create with a pre-release", action: "create", request: request("2.0.0-rc1"), violations: []string{"release-versions"}},
+		{name: "upgrade", action: "upgrade", request: request("1.3.0"), current: current},
+		{name: "upgrade to a pre-release", action: "upgrade", request: request("1.3.0-rc1"), current: current, violations: []string{"release-versions"}},
+		{name: "upgrade across majors", action: "upgrade", request: request("2.0.0"), current: current, violations: []string{"same-major"}},
+		{name: "rollback", action: "rollback", request: request("1.1.0"), current: current, violations: []string{"no-prod-rollbacks"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := admission.Admit(test.action, test.request, test.current, nil)
+			if len(test.violations) == 0 {
+				if err != nil {
+					t.Fatalf("expected the request to be admitted, got %v", err)
+				}
+
+				return
+			}
+
+			var admissionErr *models.AdmissionError
+			if !errors.As(err, &admissionErr) || len(admissionErr.Violations) != len(test.violations) {
+				t.Fatalf("expected violations %v, got %v", test.violations, err)
+			}
+			for idx, violation := range admissionErr.Violations {
+				if violation.Path != test.violations[idx] {
+					t.Fatalf("expected violations %v, got %+v", test.violations, admissionErr.Violations)
+				}
+			}
+		})
+	}
+}
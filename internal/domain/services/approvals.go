@@ -0,0 +1,371 @@
+package services
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"path"
+	"sort"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const (
+	defaultApprovalExpiry   = 24 * time.Hour
+	defaultApprovalRequired = 1
+	approvalSweepInterval   = time.Minute
+)
+
+type Approvals struct {
+	storage    ports.Storage
+	events     chan<- any
+	namespaces []string
+	required   int
+	expiry     time.Duration
+	votes      sync.Mutex
+	mutex      sync.Mutex
+	done       chan struct{}
+	group      sync.WaitGroup
+}
+
+func NewApprovals(conf *config.Config, events chan any, storage ports.Storage) usecases.Approvals {
+	return &Approvals{
+		storage:    storage,
+		events:     events,
+		namespaces: conf.Approvals.Namespaces,
+		required:   lo.Ternary(conf.Approvals.Required > 0, conf.Approvals.Required, defaultApprovalRequired),
+		expiry:     lo.Ternary(conf.Approvals.Expiry > 0, conf.Approvals.Expiry, defaultApprovalExpiry),
+	}
+}
+
+func (ctx *Approvals) Enabled() bool {
+	return len(ctx.namespaces) > 0
+}
+
+func (ctx *Approvals) Protected(namespace string) bool {
+	return lo.SomeBy(ctx.namespaces, func(pattern string) bool {
+		matched, _ := path.Match(pattern, namespace)
+
+		return matched
+	})
+}
+
+// Open reuses the pending approval of the same request by the same requester,
+// so a retried command does not ask the approvers twice.
+func (ctx *Approvals) Open(
+	action string,
+	request models.DeploymentRequest,
+	fromVersion string,
+) (*models.Approval, error) {
+	ctx.votes.Lock()
+	defer ctx.votes.Unlock()
+
+	approvals, err := ctx.storage.ListApprovals()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if existing, ok := lo.Find(approvals, func(item models.Approval) bool {
+		return item.State == models.ApprovalStatePending &&
+			!item.Expired(now) &&
+			item.Action == action &&
+			item.FromVersion == fromVersion &&
+			item.Request.Requester == request.Requester &&
+			item.Request.Same(request)
+	}); ok {
+		logger.Info("deployment already awaiting approval", zap.String("approval", existing.ID), zap.String("action", action))
+
+		return &existing, nil
+	}
+
+	approval := &models.Approval{
+		ID:          uuid.NewString(),
+		Action:      action,
+		State:       models.ApprovalStatePending,
+		Request:     request,
+		FromVersion: fromVersion,
+		Required:    ctx.required,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(ctx.expiry),
+	}
+	approval.Request.Job = ""
+	approval.Request.Approval = approval.ID
+
+	if err := ctx.storage.SaveApproval(*approval); err != nil {
+		logger.Error("failed to save approval", zap.String("approval", approval.ID), zap.Error(err))
+
+		return nil, err
+	}
+
+	logger.Info(
+		"deployment awaiting approval",
+		zap.String("approval", approval.ID),
+		zap.String("action", action),
+		zap.String("instance", request.Instance),
+		zap.Int("required", approval.Required),
+	)
+
+	ctx.publish(approval, models.StatusEventPending, "waiting for approval")
+
+	return approval, nil
+}
+
+func (ctx *Approvals) Vote(id string, voter models.Identity, approve bool, comment string) (*models.Approval, error) {
+	ctx.votes.Lock()
+	defer ctx.votes.Unlock()
+
+	approval, err := ctx.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.State != models.ApprovalStatePending {
+		return nil, models.NewError(models.ErrApprovalClosed, "approval '%s' is %s", id, approval.State)
+	}
+	if voter.User == "" {
+		return nil, models.NewError(models.ErrPermissionDenied, "approval '%s' requires an identified approver", id)
+	}
+
+	vote := models.ApprovalVote{
+		Requester: voter,
+		Comment:   comment,
+		Time:      time.Now(),
+	}
+
+	if !approve {
+		approval.Rejecter = &vote
+		ctx.transition(approval, models.ApprovalStateRejected, lo.CoalesceOrEmpty(comment, "rejected by "+voter.User))
+
+		return approval, nil
+	}
+
+	if voter.User == approval.Request.Requester.User {
+		return nil, models.NewError(models.ErrPermissionDenied, "'%s' cannot approve their own request", voter.User)
+	}
+	if lo.ContainsBy(approval.Approvers, func(item models.ApprovalVote) bool {
+		return item.Requester.User == voter.User
+	}) {
+		return nil, models.NewError(models.ErrPermissionDenied, "'%s' has already approved '%s'", voter.User, id)
+	}
+
+	approval.Approvers = append(approval.Approvers, vote)
+	if len(approval.Approvers) < approval.Required {
+		approval.UpdatedAt = vote.Time
+		ctx.save(approval)
+
+		return approval, nil
+	}
+
+	ctx.transition(approval, models.ApprovalStateApproved, "approved")
+
+	return approval, nil
+}
+
+// Admit lets a request run on an approval only if it is the approved request
+// itself, and moves the approval to executing so that it runs at most once.
+func (ctx *Approvals) Admit(id string, action string, request models.DeploymentRequest) (*models.Approval, error) {
+	ctx.votes.Lock()
+	defer ctx.votes.Unlock()
+
+	approval, err := ctx.storage.GetApproval(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !approval.Runnable() || approval.Action != action || !approval.Request.Same(request) {
+		return nil, models.NewError(
+			models.ErrApprovalClosed,
+			"approval '%s' does not allow this %s of '%s'",
+			id,
+			action,
+			request.Instance,
+		)
+	}
+
+	ctx.transition(approval, models.ApprovalStateExecuting, "executing")
+
+	return approval, nil
+}
+
+// Complete records how the admitted request ended; a request deferred by a
+// freeze window keeps its approval for the deferral to run on.
+func (ctx *Approvals) Complete(approval *models.Approval, job string, err error) {
+	approval.Job = job
+
+	if errors.Is(err, models.ErrDeploymentDeferred) {
+		ctx.transition(approval, models.ApprovalStateDeferred, err.Error())
+		return
+	}
+
+	if err != nil {
+		ctx.transition(approval, models.ApprovalStateFailed, err.Error())
+		return
+	}
+
+	ctx.transition(approval, models.ApprovalStateExecuted, "")
+}
+
+func (ctx *Approvals) Get(id string) (*models.Approval, error) {
+	approval, err := ctx.storage.GetApproval(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Expired(time.Now()) {
+		ctx.expire(approval)
+	}
+
+	return approval, nil
+}
+
+func (ctx *Approvals) List(state string) ([]models.Approval, error) {
+	approvals, err := ctx.storage.ListApprovals()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.votes.Lock()
+	now := time.Now()
+	for idx := range approvals {
+		if !approvals[idx].Expired(now) {
+			continue
+		}
+
+		if current, err := ctx.Get(approvals[idx].ID); err == nil {
+			approvals[idx] = *current
+		}
+	}
+	ctx.votes.Unlock()
+
+	approvals = lo.Filter(approvals, func(item models.Approval, _ int) bool {
+		return state == "" || item.State == state
+	})
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.After(approvals[j].CreatedAt)
+	})
+
+	return approvals, nil
+}
+
+func (ctx *Approvals) Start() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done != nil {
+		return
+	}
+	ctx.recover()
+
+	if !ctx.Enabled() {
+		return
+	}
+	ctx.done = make(chan struct{})
+
+	logger.Info("starting approval expiry")
+
+	ctx.group.Add(1)
+	go ctx.sweep(ctx.done)
+}
+
+func (ctx *Approvals) Stop() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done == nil {
+		return
+	}
+	close(ctx.done)
+	ctx.done = nil
+
+	ctx.group.Wait()
+
+	logger.Info("stopped approval expiry")
+}
+
+func (ctx *Approvals) sweep(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(approvalSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := ctx.List(models.ApprovalStatePending); err != nil {
+				logger.Error("failed to list approvals", zap.Error(err))
+			}
+		}
+	}
+}
+
+// recover settles the approvals a previous leader left behind: approved
+// requests that never started run now, and requests that were running may or
+// may not have been applied, so they fail and have to be requested again.
+func (ctx *Approvals) recover() {
+	ctx.votes.Lock()
+	defer ctx.votes.Unlock()
+
+	approvals, err := ctx.storage.ListApprovals()
+	if err != nil {
+		logger.Error("failed to list approvals", zap.Error(err))
+
+		return
+	}
+
+	for _, approval := range approvals {
+		switch approval.State {
+		case models.ApprovalStateApproved:
+			logger.Info("resuming approved deployment", zap.String("approval", approval.ID), zap.String("action", approval.Action))
+
+			ctx.events <- approval.Command()
+		case models.ApprovalStateExecuting:
+			logger.Warn("approved deployment was interrupted", zap.String("approval", approval.ID), zap.String("action", approval.Action))
+
+			ctx.transition(&approval, models.ApprovalStateFailed, "interrupted by a restart, request the deployment again")
+		}
+	}
+}
+
+func (ctx *Approvals) expire(approval *models.Approval) {
+	ctx.transition(approval, models.ApprovalStateExpired, "approval expired")
+}
+
+func (ctx *Approvals) transition(approval *models.Approval, state, message string) {
+	approval.State = state
+	approval.Message = message
+	approval.UpdatedAt = time.Now()
+
+	ctx.save(approval)
+
+	switch state {
+	case models.ApprovalStateApproved:
+		ctx.publish(approval, models.StatusEventApproved, message)
+	case models.ApprovalStateRejected:
+		ctx.publish(approval, models.StatusEventDenied, message)
+	case models.ApprovalStateExpired:
+		ctx.publish(approval, models.StatusEventExpired, message)
+	}
+}
+
+func (ctx *Approvals) save(approval *models.Approval) {
+	if err := ctx.storage.SaveApproval(*approval); err != nil {
+		logger.Error("failed to save approval", zap.String("approval", approval.ID), zap.Error(err))
+	}
+}
+
+func (ctx *Approvals) publish(approval *models.Approval, eventType, message string) {
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(eventType, approval.Request, message),
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+)
+
+func newTestApprovals(required int) (*Approvals, *memoryStorage, chan any) {
+	storage := newMemoryStorage()
+	events := make(chan any, 64)
+	conf := &config.Config{Approvals: config.ApprovalsConfig{Namespaces: []string{"prod*"}, Required: required}}
+
+	return NewApprovals(conf, events, storage).(*Approvals), storage, events
+}
+
+func approvalRequest(version string) models.DeploymentRequest {
+	return models.DeploymentRequest{
+		Service:   "api",
+		Instance:  "api-prod",
+		Version:   version,
+		Namespace: "prod",
+		Cluster:   "default",
+		Requester: models.Identity{User: "alice"},
+		Values:    map[string]string{"replicaCount": "3"},
+	}
+}
+
+func TestApprovalsOpen(t *testing.T) {
+	approvals, _, _ := newTestApprovals(1)
+
+	first, err := approvals.Open("upgrade", approvalRequest("1.1.0"), "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retried, err := approvals.Open("upgrade", approvalRequest("1.1.0"), "1.0.0")
+	if err != nil || retried.ID != first.ID {
+		t.Fatalf("expected the pending approval %s to be reused, got %+v (%v)", first.ID, retried, err)
+	}
+
+	other, err := approvals.Open("upgrade", approvalRequest("1.2.0"), "1.0.0")
+	if err != nil || other.ID == first.ID {
+		t.Fatalf("expected a new approval for another version, got %+v (%v)", other, err)
+	}
+
+	values := approvalRequest("1.1.0")
+	values.Values = map[string]string{"replicaCount": "30"}
+	other, err = approvals.Open("upgrade", values, "1.0.0")
+	if err != nil || other.ID == first.ID {
+		t.Fatalf("expected a new approval for other values, got %+v (%v)", other, err)
+	}
+
+	requester := approvalRequest("1.1.0")
+	requester.Requester = models.Identity{User: "mallory"}
+	other, err = approvals.Open("upgrade", requester, "1.0.0")
+	if err != nil || other.ID == first.ID {
+		t.Fatalf("expected a new approval for another requester, got %+v (%v)", other, err)
+	}
+
+	if _, err = approvals.Vote(first.ID, models.Identity{User: "bob"}, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := approvals.Open("upgrade", approvalRequest("1.1.0"), "1.0.0")
+	if err != nil || reopened.ID == first.ID {
+		t.Fatalf("expected a new approval after a rejection, got %+v (%v)", reopened, err)
+	}
+}
+
+func TestApprovalsVote(t *testing.T) {
+	type vote struct {
+		user    string
+		approve bool
+	}
+
+	tests := []struct {
+		name     string
+		required int
+		votes    []vote
+		state    string
+		denied   bool
+		closed   bool
+	}{
+		{name: "single approval", required: 1, votes: []vote{{"bob", true}}, state: models.ApprovalStateApproved},
+		{name: "partial approval", required: 2, votes: []vote{{"bob", true}}, state: models.ApprovalStatePending},
+		{name: "two approvals", required: 2, votes: []vote{{"bob", true}, {"carol", true}}, state: models.ApprovalStateApproved},
+		{name: "rejection", required: 2, votes: []vote{{"bob", true}, {"carol", false}}, state: models.ApprovalStateRejected},
+		{name: "own request", required: 1, votes: []vote{{"alice", true}}, denied: true},
+		{name: "anonymous approver", required: 1, votes: []vote{{"", true}}, denied: true},
+		{name: "repeated approver", required: 2, votes: []vote{{"bob", true}, {"bob", true}}, denied: true},
+		{name: "vote after the decision", required: 1, votes: []vote{{"bob", true}, {"carol", true}}, closed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approvals, _, _ := newTestApprovals(test.required)
+
+			approval, err := approvals.Open("create", approvalRequest("1.0.0"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, item := range test.votes {
+				if approval, err = approvals.Vote(approval.ID, models.Identity{User: item.user}, item.approve, ""); err != nil {
+					break
+				}
+			}
+
+			switch {
+			case test.denied:
+				if !errors.Is(err, models.ErrPermissionDenied) {
+					t.Fatalf("expected the vote to be denied, got %v", err)
+				}
+			case test.closed:
+				if !errors.Is(err, models.ErrApprovalClosed) {
+					t.Fatalf("expected the approval to be closed, got %v", err)
+				}
+			case err != nil || approval.State != test.state:
+				t.Fatalf("expected state %s, got %+v (%v)", test.state, approval, err)
+			}
+		})
+	}
+}
+
+func TestApprovalsAdmit(t *testing.T) {
+	changed := func(change func(request *models.DeploymentRequest)) models.DeploymentRequest {
+		request := approvalRequest("1.1.0")
+		change(&request)
+
+		return request
+	}
+
+	tests := []struct {
+		name    string
+		action  string
+		request models.DeploymentRequest
+		vote    bool
+		closed  bool
+	}{
+		{name: "approved request", action: "upgrade", request: approvalRequest("1.1.0"), vote: true},
+		{name: "pending approval", action: "upgrade", request: approvalRequest("1.1.0"), closed: true},
+		{name: "other action", action: "create", request: approvalRequest("1.1.0"), vote: true, closed: true},
+		{name: "other version", action: "upgrade", request: approvalRequest("2.0.0"), vote: true, closed: true},
+		{
+			name:    "other values",
+			action:  "upgrade",
+			request: changed(func(request *models.DeploymentRequest) { request.Values = map[string]string{"replicaCount": "30"} }),
+			vote:    true,
+			closed:  true,
+		},
+		{
+			name:    "other cluster",
+			action:  "upgrade",
+			request: changed(func(request *models.DeploymentRequest) { request.Cluster = "other" }),
+			vote:    true,
+			closed:  true,
+		},
+		{
+			name:    "other namespace",
+			action:  "upgrade",
+			request: changed(func(request *models.DeploymentRequest) { request.Namespace = "prod-eu" }),
+			vote:    true,
+			closed:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approvals, _, _ := newTestApprovals(1)
+
+			approval, err := approvals.Open("upgrade", approvalRequest("1.1.0"), "1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.vote {
+				if _, err = approvals.Vote(approval.ID, models.Identity{User: "bob"}, true, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			admitted, err := approvals.Admit(approval.ID, test.action, test.request)
+			if test.closed {
+				if !errors.Is(err, models.ErrApprovalClosed) {
+					t.Fatalf("expected the request to be refused, got %+v (%v)", admitted, err)
+				}
+
+				return
+			}
+
+			if err != nil || admitted.State != models.ApprovalStateExecuting {
+				t.Fatalf("expected the approval to be executing, got %+v (%v)", admitted, err)
+			}
+
+			if _, err = approvals.Admit(approval.ID, test.action, test.request); !errors.Is(err, models.ErrApprovalClosed) {
+				t.Fatalf("expected a second execution to be refused, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApprovalsCompleteDeferred(t *testing.T) {
+	approvals, _, _ := newTestApprovals(1)
+
+	approval, err := approvals.Open("upgrade", approvalRequest("1.1.0"), "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = approvals.Vote(approval.ID, models.Identity{User: "bob"}, true, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	admitted, err := approvals.Admit(approval.ID, "upgrade", approvalRequest("1.1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	approvals.Complete(admitted, "", &models.DeferredError{Deferral: &models.Deferral{ID: "deferral"}})
+
+	if admitted.State != models.ApprovalStateDeferred {
+		t.Fatalf("expected the approval to be deferred, got %s", admitted.State)
+	}
+	if _, err = approvals.Admit(approval.ID, "upgrade", approvalRequest("1.1.0")); err != nil {
+		t.Fatalf("expected the deferral to run on the approval, got %v", err)
+	}
+}
+
+func TestApprovalsRecover(t *testing.T) {
+	approvals, storage, events := newTestApprovals(1)
+
+	for id, state := range map[string]string{
+		"approved":  models.ApprovalStateApproved,
+		"executing": models.ApprovalStateExecuting,
+		"deferred":  models.ApprovalStateDeferred,
+		"pending":   models.ApprovalStatePending,
+	} {
+		approval := models.Approval{ID: id, Action: "upgrade", State: state, Request: approvalRequest("1.1.0")}
+		if err := storage.SaveApproval(approval); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	approvals.recover()
+	close(events)
+
+	var commands []*models.KafkaMessage
+	for event := range events {
+		if command, ok := event.(*models.KafkaMessage); ok {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) != 1 || commands[0].Approval != "approved" || !commands[0].Internal {
+		t.Fatalf("expected the approved request to run again, got %+v", commands)
+	}
+
+	for id, state := range map[string]string{
+		"approved":  models.ApprovalStateApproved,
+		"executing": models.ApprovalStateFailed,
+		"deferred":  models.ApprovalStateDeferred,
+		"pending":   models.ApprovalStatePending,
+	} {
+		approval, err := storage.GetApproval(id)
+		if err != nil || approval.State != state {
+			t.Fatalf("expected approval %s to be %s, got %+v (%v)", id, state, approval, err)
+		}
+	}
+}
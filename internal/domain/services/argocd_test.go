@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"github.com/samber/lo"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+)
+
+// memoryArgocd serves the applications it was given and leaves every other
+// call to the embedded nil port, so a test touching them panics.
+type memoryArgocd struct {
+	ports.Argocd
+	applications []models.Application
+}
+
+func (ctx *memoryArgocd) GetList(context.Context) ([]models.Application, error) {
+	return ctx.applications, nil
+}
+
+func (ctx *memoryArgocd) Get(_ context.Context, instance string) (*models.Application, error) {
+	application, ok := lo.Find(ctx.applications, func(item models.Application) bool {
+		return item.Instance == instance
+	})
+	if !ok {
+		return nil, models.ErrApplicationNotFound
+	}
+
+	return &application, nil
+}
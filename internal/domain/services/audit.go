@@ -0,0 +1,181 @@
+package services
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+type Audit struct {
+	log    ports.AuditLog
+	key    []byte
+	mutex  sync.Mutex
+	head   models.AuditEntry
+	broken error
+}
+
+func NewAudit(conf *config.Config, log ports.AuditLog) usecases.Audit {
+	audit := &Audit{
+		log: log,
+		key: []byte(conf.Audit.Key),
+	}
+	if len(audit.key) == 0 {
+		logger.Warn("audit log has no key, its hash chain can be recomputed by anyone who can write the file")
+	}
+
+	verification := audit.Verify()
+	if !verification.Valid {
+		logger.Error(
+			"audit log chain is broken",
+			zap.Int("entries", verification.Entries),
+			zap.String("error", verification.Error),
+		)
+	}
+
+	return audit
+}
+
+func (ctx *Audit) Record(action string, request models.DeploymentRequest, err error) {
+	entry := auditEntry(action, request)
+	if err != nil {
+		entry.Result = lo.Ternary(
+			errors.Is(err, models.ErrPermissionDenied),
+			models.AuditResultDenied,
+			models.AuditResultFailed,
+		)
+		entry.Message = err.Error()
+	}
+
+	ctx.append(entry)
+}
+
+func (ctx *Audit) Notice(action string, request models.DeploymentRequest, message string) {
+	entry := auditEntry(action, request)
+	entry.Message = message
+
+	logger.Warn(
+		message,
+		zap.String("action", action),
+		zap.String("instance", entry.Instance),
+		zap.String("user", entry.Requester.User),
+		zap.String("team", entry.Requester.Team),
+		zap.String("system", entry.Requester.System),
+	)
+
+	ctx.append(entry)
+}
+
+// append refuses to extend a chain that failed verification, as its head may be
+// an entry in the middle of the file; a Verify once the file is repaired resumes it.
+func (ctx *Audit) append(entry models.AuditEntry) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.broken != nil {
+		logger.Error(
+			"audit log chain is broken, dropping entry",
+			zap.String("action", entry.Action),
+			zap.String("instance", entry.Instance),
+			zap.Error(ctx.broken),
+		)
+
+		return
+	}
+
+	entry.Sequence = ctx.head.Sequence + 1
+	entry.Previous = ctx.head.Hash
+	entry.Hash = entry.Digest(ctx.key)
+
+	if err := ctx.log.Append(entry); err != nil {
+		logger.Error("failed to append audit entry", zap.Uint64("sequence", entry.Sequence), zap.Error(err))
+
+		return
+	}
+	ctx.head = entry
+
+	logger.Info(
+		"audit",
+		zap.String("action", entry.Action),
+		zap.String("instance", entry.Instance),
+		zap.String("user", entry.Requester.User),
+		zap.String("team", entry.Requester.Team),
+		zap.String("system", entry.Requester.System),
+		zap.String("result", entry.Result),
+	)
+}
+
+func (ctx *Audit) Verify() models.AuditVerification {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	entries, err := ctx.log.Entries()
+	if err != nil {
+		ctx.broken = err
+
+		return models.AuditVerification{Entries: len(entries), Error: err.Error()}
+	}
+
+	var previous models.AuditEntry
+	for index, entry := range entries {
+		switch {
+		case entry.Sequence != previous.Sequence+1:
+			err = fmt.Errorf("entry %d has sequence %d", index+1, entry.Sequence)
+		case entry.Previous != previous.Hash:
+			err = fmt.Errorf("entry %d does not chain to entry %d", entry.Sequence, previous.Sequence)
+		case !hmac.Equal([]byte(entry.Hash), []byte(entry.Digest(ctx.key))):
+			err = fmt.Errorf("entry %d has been modified", entry.Sequence)
+		}
+		if err != nil {
+			break
+		}
+
+		previous = entry
+	}
+
+	ctx.broken = err
+	if err == nil {
+		ctx.head = previous
+	}
+
+	verification := models.AuditVerification{
+		Valid:   err == nil,
+		Entries: len(entries),
+		Head:    previous.Hash,
+	}
+	if err != nil {
+		verification.Error = err.Error()
+	}
+
+	return verification
+}
+
+func (ctx *Audit) Health() models.HealthCheck {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return models.NewHealthCheck("audit", ctx.broken)
+}
+
+func auditEntry(action string, request models.DeploymentRequest) models.AuditEntry {
+	return models.AuditEntry{
+		Time:      time.Now().UTC(),
+		Action:    action,
+		Requester: request.Requester,
+		Job:       request.Job,
+		Service:   request.Service,
+		Instance:  request.Instance,
+		Namespace: request.Namespace,
+		Cluster:   request.Cluster,
+		Version:   request.Version,
+		Result:    models.AuditResultSucceeded,
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+)
+
+type memoryAuditLog struct {
+	entries []models.AuditEntry
+	err     error
+}
+
+func (ctx *memoryAuditLog) Append(entry models.AuditEntry) error {
+	ctx.entries = append(ctx.entries, entry)
+
+	return nil
+}
+
+func (ctx *memoryAuditLog) Entries() ([]models.AuditEntry, error) {
+	return append([]models.AuditEntry(nil), ctx.entries...), ctx.err
+}
+
+func (ctx *memoryAuditLog) Close() error {
+	return nil
+}
+
+func TestAuditVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		tamper func(log *memoryAuditLog)
+		error  string
+	}{
+		{
+			name: "intact chain",
+			key:  "secret",
+		},
+		{
+			name: "intact unkeyed chain",
+		},
+		{
+			name: "modified entry",
+			key:  "secret",
+			tamper: func(log *memoryAuditLog) {
+				log.entries[1].Version = "9.9.9"
+			},
+			error: "entry 2 has been modified",
+		},
+		{
+			name: "recomputed chain without the key",
+			key:  "secret",
+			tamper: func(log *memoryAuditLog) {
+				log.entries[1].Version = "9.9.9"
+				for idx := 1; idx < len(log.entries); idx++ {
+					log.entries[idx].Previous = log.entries[idx-1].Hash
+					log.entries[idx].Hash = log.entries[idx].Digest(nil)
+				}
+			},
+			error: "entry 2 has been modified",
+		},
+		{
+			name: "removed entry",
+			key:  "secret",
+			tamper: func(log *memoryAuditLog) {
+				log.entries = append(log.entries[:1], log.entries[2:]...)
+			},
+			error: "entry 2 has sequence 3",
+		},
+		{
+			name: "verified with another key",
+			key:  "secret",
+			tamper: func(log *memoryAuditLog) {
+				for idx := range log.entries {
+					log.entries[idx].Hash = log.entries[idx].Digest([]byte("other"))
+				}
+			},
+			error: "entry 1 has been modified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := &memoryAuditLog{}
+			conf := &config.Config{Audit: config.AuditConfig{Key: test.key}}
+
+			audit := NewAudit(conf, log)
+			for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+				audit.Record("upgrade", models.DeploymentRequest{Instance: "api", Version: version}, nil)
+			}
+
+			if test.tamper != nil {
+				test.tamper(log)
+			}
+
+			verification := NewAudit(conf, log).Verify()
+			if test.error == "" {
+				if !verification.Valid || verification.Entries != 3 {
+					t.Fatalf("expected a valid chain of 3 entries, got %+v", verification)
+				}
+
+				return
+			}
+
+			if verification.Valid || !strings.Contains(verification.Error, test.error) {
+				t.Fatalf("expected error %q, got %+v", test.error, verification)
+			}
+		})
+	}
+}
+
+func TestAuditBrokenChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(log *memoryAuditLog)
+		repair func(log *memoryAuditLog)
+	}{
+		{
+			name: "modified entry",
+			tamper: func(log *memoryAuditLog) {
+				log.entries[1].Version = "9.9.9"
+			},
+			repair: func(log *memoryAuditLog) {
+				log.entries[1].Version = "1.1.0"
+			},
+		},
+		{
+			name: "unparseable entry",
+			tamper: func(log *memoryAuditLog) {
+				log.err = errors.New("failed to parse audit entry 3")
+			},
+			repair: func(log *memoryAuditLog) {
+				log.err = nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := &memoryAuditLog{}
+			conf := &config.Config{Audit: config.AuditConfig{Key: "secret"}}
+
+			audit := NewAudit(conf, log)
+			for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+				audit.Record("upgrade", models.DeploymentRequest{Instance: "api", Version: version}, nil)
+			}
+
+			test.tamper(log)
+
+			audit = NewAudit(conf, log)
+			audit.Record("upgrade", models.DeploymentRequest{Instance: "api", Version: "1.3.0"}, nil)
+			if len(log.entries) != 3 {
+				t.Fatalf("expected no entry appended to a broken chain, got %d entries", len(log.entries))
+			}
+			if audit.Health().Healthy {
+				t.Fatal("expected the audit check to fail while the chain is broken")
+			}
+
+			test.repair(log)
+
+			if verification := audit.Verify(); !verification.Valid {
+				t.Fatalf("expected the repaired chain to verify, got %+v", verification)
+			}
+			audit.Record("upgrade", models.DeploymentRequest{Instance: "api", Version: "1.3.0"}, nil)
+			if len(log.entries) != 4 || log.entries[3].Sequence != 4 || log.entries[3].Previous != log.entries[2].Hash {
+				t.Fatalf("expected the entry to chain onto the repaired head, got %+v", log.entries)
+			}
+			if !audit.Health().Healthy {
+				t.Fatal("expected the audit check to pass once the chain verifies")
+			}
+		})
+	}
+}
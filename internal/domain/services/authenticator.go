@@ -0,0 +1,43 @@
+package services
+
+import (
+	"crypto/subtle"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+)
+
+type Authenticator struct {
+	tokens []config.AuthenticationTokenConfig
+}
+
+func NewAuthenticator(conf *config.Config) usecases.Authenticator {
+	return &Authenticator{
+		tokens: conf.Authentication.Tokens,
+	}
+}
+
+func (ctx *Authenticator) Enabled() bool {
+	return len(ctx.tokens) > 0
+}
+
+// Authenticate compares the token with every configured one, so the time it
+// takes does not tell how much of a token was right.
+func (ctx *Authenticator) Authenticate(token string) (models.Identity, error) {
+	var (
+		identity models.Identity
+		matched  bool
+	)
+	for _, item := range ctx.tokens {
+		if subtle.ConstantTimeCompare([]byte(item.Token), []byte(token)) == 1 && token != "" {
+			identity = models.Identity{User: item.User, Team: item.Team}
+			matched = true
+		}
+	}
+
+	if !matched {
+		return models.Identity{}, models.NewError(models.ErrUnauthenticated, "missing or invalid bearer token")
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"github.com/samber/lo"
+	"path"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+)
+
+type Authorizer struct {
+	enabled bool
+	rules   []config.AuthorizationRuleConfig
+}
+
+func NewAuthorizer(conf *config.Config) usecases.Authorizer {
+	return &Authorizer{
+		enabled: conf.Authorization.Enabled,
+		rules:   conf.Authorization.Rules,
+	}
+}
+
+func (ctx *Authorizer) Enabled() bool {
+	return ctx.enabled
+}
+
+func (ctx *Authorizer) Authorize(action string, request models.DeploymentRequest) error {
+	if !ctx.enabled {
+		return nil
+	}
+
+	requester := request.Requester
+	for _, rule := range ctx.rules {
+		if !ctx.subject(rule, requester) {
+			continue
+		}
+
+		if allows(rule.Actions, strings.ToLower(action)) &&
+			allows(rule.Services, request.Service) &&
+			allows(rule.Namespaces, request.Namespace) &&
+			allows(rule.Clusters, request.Cluster) {
+			return nil
+		}
+	}
+
+	return models.NewError(
+		models.ErrPermissionDenied,
+		"%s is not allowed to %s service '%s' in namespace '%s' on cluster '%s'",
+		principal(requester),
+		action,
+		request.Service,
+		request.Namespace,
+		request.Cluster,
+	)
+}
+
+func (ctx *Authorizer) subject(rule config.AuthorizationRuleConfig, requester models.Identity) bool {
+	return lo.Contains(rule.Users, "*") ||
+		(requester.User != "" && lo.Contains(rule.Users, requester.User)) ||
+		(requester.Team != "" && lo.Contains(rule.Teams, requester.Team)) ||
+		(requester.System != "" && lo.Contains(rule.Systems, requester.System))
+}
+
+func allows(patterns []string, value string) bool {
+	return len(patterns) == 0 || lo.SomeBy(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, value)
+
+		return matched
+	})
+}
+
+func principal(requester models.Identity) string {
+	name := lo.CoalesceOrEmpty(requester.User, "anonymous")
+	if requester.Team != "" {
+		name += " (" + requester.Team + ")"
+	}
+
+	return "'" + name + "'"
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+)
+
+func TestAuthorizerAuthorize(t *testing.T) {
+	rules := []config.AuthorizationRuleConfig{
+		{Name: "developers", Teams: []string{"payments"}, Actions: []string{"fetch", "upgrade"}, Namespaces: []string{"dev-*"}},
+		{Name: "release", Users: []string{"release-bot"}, Services: []string{"api"}, Clusters: []string{"prod-*"}},
+		{Name: "reconciler", Systems: []string{models.SystemReconciler}, Actions: []string{"delete"}},
+		{Name: "readers", Users: []string{"*"}, Actions: []string{"fetch"}},
+	}
+	request := func(requester models.Identity, namespace, cluster string) models.DeploymentRequest {
+		return models.DeploymentRequest{
+			Service:   "api",
+			Namespace: namespace,
+			Cluster:   cluster,
+			Requester: requester,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		action  string
+		request models.DeploymentRequest
+		denied  bool
+	}{
+		{
+			name:    "disabled authorization",
+			action:  "delete",
+			request: request(models.Identity{}, "prod", "prod-eu"),
+		},
+		{
+			name:    "team within its namespaces",
+			enabled: true,
+			action:  "Upgrade",
+			request: request(models.Identity{User: "alice", Team: "payments"}, "dev-alice", "dev"),
+		},
+		{
+			name:    "team outside its namespaces",
+			enabled: true,
+			action:  "upgrade",
+			request: request(models.Identity{User: "alice", Team: "payments"}, "prod", "dev"),
+			denied:  true,
+		},
+		{
+			name:    "team outside its actions",
+			enabled: true,
+			action:  "delete",
+			request: request(models.Identity{User: "alice", Team: "payments"}, "dev-alice", "dev"),
+			denied:  true,
+		},
+		{
+			name:    "user with every action on its clusters",
+			enabled: true,
+			action:  "rollback",
+			request: request(models.Identity{User: "release-bot"}, "prod", "prod-eu"),
+		},
+		{
+			name:    "user on another cluster",
+			enabled: true,
+			action:  "rollback",
+			request: request(models.Identity{User: "release-bot"}, "prod", "staging"),
+			denied:  true,
+		},
+		{
+			name:    "system rule",
+			enabled: true,
+			action:  "delete",
+			request: request(models.Identity{System: models.SystemReconciler}, "prod", "prod-eu"),
+		},
+		{
+			name:    "system rule for another system",
+			enabled: true,
+			action:  "delete",
+			request: request(models.Identity{User: "mallory", System: models.SystemREST}, "prod", "prod-eu"),
+			denied:  true,
+		},
+		{
+			name:    "wildcard user",
+			enabled: true,
+			action:  "fetch",
+			request: request(models.Identity{}, "prod", "prod-eu"),
+		},
+		{
+			name:    "wildcard user outside its actions",
+			enabled: true,
+			action:  "create",
+			request: request(models.Identity{User: "mallory"}, "prod", "prod-eu"),
+			denied:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authorizer := NewAuthorizer(&config.Config{
+				Authorization: config.AuthorizationConfig{Enabled: test.enabled, Rules: rules},
+			})
+
+			err := authorizer.Authorize(test.action, test.request)
+			if test.denied != errors.Is(err, models.ErrPermissionDenied) || (!test.denied && err != nil) {
+				t.Fatalf("expected denied=%v, got %v", test.denied, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorAuthenticate(t *testing.T) {
+	authenticator := NewAuthenticator(&config.Config{
+		Authentication: config.AuthenticationConfig{
+			Tokens: []config.AuthenticationTokenConfig{
+				{Token: "alice-token", User: "alice", Team: "payments"},
+				{Token: "ci-token", User: "ci"},
+			},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		expected models.Identity
+		invalid  bool
+	}{
+		{name: "user and team", token: "alice-token", expected: models.Identity{User: "alice", Team: "payments"}},
+		{name: "user only", token: "ci-token", expected: models.Identity{User: "ci"}},
+		{name: "unknown token", token: "alice", invalid: true},
+		{name: "missing token", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := authenticator.Authenticate(test.token)
+			if test.invalid {
+				if !errors.Is(err, models.ErrUnauthenticated) {
+					t.Fatalf("expected an authentication error, got %+v (%v)", identity, err)
+				}
+
+				return
+			}
+
+			if err != nil || identity != test.expected {
+				t.Fatalf("expected %+v, got %+v (%v)", test.expected, identity, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizerVisible(t *testing.T) {
+	conf := &config.Config{Authorization: config.AuthorizationConfig{Enabled: true, Rules: []config.AuthorizationRuleConfig{
+		{Teams: []string{"payments"}, Actions: []string{"fetch"}, Namespaces: []string{"dev"}},
+		{Users: []string{"admin"}, Actions: []string{"fetch"}},
+	}}}
+	authorizer := NewAuthorizer(conf)
+	payments := models.Identity{Team: "payments"}
+	admin := models.Identity{User: "admin"}
+
+	records := []models.HistoryRecord{{Service: "api", Namespace: "dev"}, {Service: "api", Namespace: "prod"}}
+	if visible, ok := authorizer.Visible(payments, records); !ok || len(visible.([]models.HistoryRecord)) != 1 {
+		t.Fatalf("expected only the dev record, got %+v", visible)
+	}
+
+	job := &models.Job{ID: "job", Service: "api", Namespace: "prod"}
+	if _, ok := authorizer.Visible(payments, job); ok {
+		t.Fatal("expected the prod job to be hidden")
+	}
+	if _, ok := authorizer.Visible(admin, job); !ok {
+		t.Fatal("expected the prod job to be visible to an unscoped reader")
+	}
+
+	plan := &models.Plan{Steps: []models.PlanStep{{Service: "api", Namespace: "dev"}, {Service: "api", Namespace: "prod"}}}
+	if visible, ok := authorizer.Visible(payments, plan); !ok || len(visible.(*models.Plan).Steps) != 1 || len(plan.Steps) != 2 {
+		t.Fatalf("expected a copy of the plan with the dev step, got %+v", visible)
+	}
+
+	security := &models.SecurityEvent{Reason: models.SecurityReasonInvalid}
+	if _, ok := authorizer.Visible(payments, security); ok {
+		t.Fatal("expected security events to be hidden from scoped readers")
+	}
+	if _, ok := authorizer.Visible(admin, security); !ok {
+		t.Fatal("expected security events to be visible to an unscoped reader")
+	}
+}
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"strings"
 	"tera/deployment/internal/domain/models"
@@ -11,80 +14,968 @@ import (
 	"tera/deployment/internal/usecases"
 	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/metrics"
+	"tera/deployment/pkg/tracing"
+	"time"
 )
 
 type DeploymentManager struct {
-	argocd   ports.Argocd
-	events   chan<- any
-	services []config.ServiceConfig
+	argocd      ports.Argocd
+	validator   usecases.ValuesValidator
+	history     usecases.History
+	audit       usecases.Audit
+	authorizer  usecases.Authorizer
+	admission   usecases.AdmissionController
+	approvals   usecases.Approvals
+	freeze      usecases.Freeze
+	jobs        usecases.JobTracker
+	locks       usecases.OperationLock
+	events      chan<- any
+	services    []config.ServiceConfig
+	clusters    []string
+	environment string
 }
 
 func NewDeploymentManager(
 	conf *config.Config,
 	events chan any,
 	argocd ports.Argocd,
+	validator usecases.ValuesValidator,
+	history usecases.History,
+	audit usecases.Audit,
+	authorizer usecases.Authorizer,
+	admission usecases.AdmissionController,
+	approvals usecases.Approvals,
+	freeze usecases.Freeze,
+	jobs usecases.JobTracker,
+	locks usecases.OperationLock,
 ) usecases.DeploymentManager {
 	return &DeploymentManager{
-		argocd:   argocd,
-		events:   events,
-		services: conf.Services,
+		argocd:      argocd,
+		validator:   validator,
+		history:     history,
+		audit:       audit,
+		authorizer:  authorizer,
+		admission:   admission,
+		approvals:   approvals,
+		freeze:      freeze,
+		jobs:        jobs,
+		locks:       locks,
+		events:      events,
+		services:    conf.Services,
+		clusters:    clusterNames(conf.Clusters),
+		environment: conf.Profile,
 	}
 }
 
-func (ctx *DeploymentManager) GetList() ([]models.Application, error) {
-	applications, err := ctx.argocd.GetList()
+func (ctx *DeploymentManager) GetList(background context.Context) ([]models.Application, error) {
+	applications, err := ctx.argocd.GetList(background)
 	if err != nil {
 		return nil, err
 	}
 
+	requester := models.RequesterFrom(background)
+	applications = lo.Filter(applications, func(item models.Application, _ int) bool {
+		return ctx.hasService(item.Service) && ctx.authorizer.Authorize("fetch", fetchRequest(item, requester)) == nil
+	})
+
 	ctx.events <- &models.SystemMessage{
 		Key:   models.ArgocdApplicationList,
 		Value: applications,
 	}
 
-	return lo.FilterMap(applications, func(item models.Application, _ int) (models.Application, bool) {
-		return item, ctx.hasService(item.Name)
-	}), nil
+	return applications, nil
 }
 
-func (ctx *DeploymentManager) Create(service, version, namespace string, values map[string]string) (*models.Application, error) {
-	if namespace == "" {
-		namespace = service
+func (ctx *DeploymentManager) Get(background context.Context, instance string) (*models.Application, error) {
+	application, err := ctx.argocd.Get(background, strings.ToLower(instance))
+	if err != nil {
+		return nil, err
 	}
 
-	if !ctx.hasService(service) {
-		logger.Warn("service not found", zap.String("service", service))
+	if err = ctx.authorizer.Authorize("fetch", fetchRequest(*application, models.RequesterFrom(background))); err != nil {
+		return nil, err
+	}
 
-		return nil, errors.New("service not found")
+	return application, nil
+}
+
+func (ctx *DeploymentManager) Create(
+	background context.Context,
+	request models.DeploymentRequest,
+) (application *models.Application, err error) {
+	if request.Namespace == "" {
+		request.Namespace = request.Service
+	}
+	if request.Cluster == "" {
+		request.Cluster = models.DefaultCluster
 	}
+	if request.Instance == "" {
+		request.Instance = models.InstanceName(request.Service, request.Namespace)
+	}
+	request.Instance = strings.ToLower(request.Instance)
+	request.Version = lo.CoalesceOrEmpty(request.Version, ctx.chartVersion(request.Service))
 
-	if depends := ctx.findDepends(service); len(depends) > 0 {
-		message := fmt.Sprintf(
-			"service '%s' cannot be installed because the following dependencies are missing: %v",
-			service,
-			depends,
+	ctx.history.RecordCommand("create", request)
+
+	var approval *models.Approval
+	defer func() { ctx.complete(approval, application, err) }()
+
+	if approval, err = ctx.requireApproval("create", request, ""); err != nil {
+		return nil, err
+	}
+
+	if err = ctx.checkFreeze("create", request, 0); err != nil {
+		return nil, err
+	}
+
+	job := ctx.jobs.Begin("create", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		return ctx.track(job, nil, err)
+	}
+	defer release()
+
+	application, err = ctx.create(background, job, request)
+
+	return ctx.track(job, application, err)
+}
+
+func (ctx *DeploymentManager) create(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	if !ctx.hasService(request.Service) {
+		logger.Warn("service not found", zap.String("service", request.Service))
+
+		return nil, ctx.reject(request, models.ErrServiceNotFound)
+	}
+
+	if err := ctx.authorize("create", request); err != nil {
+		return nil, err
+	}
+
+	deployed, err := ctx.prepare(background, &request, nil)
+	if err != nil {
+		return nil, ctx.reject(request, err)
+	}
+
+	if err = ctx.admission.Admit("create", request, nil, deployed); err != nil {
+		ctx.audit.Record("create", request, err)
+
+		return nil, ctx.reject(request, err)
+	}
+
+	ctx.jobs.Run(job)
+
+	application, err := ctx.argocd.Create(background, request)
+	ctx.history.RecordOperation("create", request, err)
+	ctx.audit.Record("create", request, err)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.syncOverride(background, request)
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventCreated, request, "application created"),
+	}
+
+	return application, nil
+}
+
+func (ctx *DeploymentManager) Upgrade(
+	background context.Context,
+	request models.DeploymentRequest,
+) (application *models.Application, err error) {
+	request.Instance = strings.ToLower(request.Instance)
+
+	ctx.history.RecordCommand("upgrade", request)
+
+	var approval *models.Approval
+	defer func() { ctx.complete(approval, application, err) }()
+
+	if ctx.freeze.Enabled() || ctx.approvals.Enabled() || request.Approval != "" {
+		var planned models.DeploymentRequest
+		var fromVersion string
+		if planned, fromVersion, err = ctx.resolve(background, request); err != nil {
+			return nil, err
+		}
+
+		if approval, err = ctx.requireApproval("upgrade", planned, fromVersion); err != nil {
+			return nil, err
+		}
+
+		if err = ctx.checkFreeze("upgrade", planned, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	job := ctx.jobs.Begin("upgrade", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		return ctx.track(job, nil, err)
+	}
+	defer release()
+
+	application, err = ctx.upgrade(background, job, request)
+
+	return ctx.track(job, application, err)
+}
+
+func (ctx *DeploymentManager) upgrade(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	current, err := ctx.argocd.Get(background, request.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Service != "" && request.Service != current.Service {
+		return nil, ctx.reject(request, models.NewError(
+			models.ErrServiceMismatch,
+			"application '%s' deploys service '%s', not '%s'",
+			request.Instance,
+			current.Service,
+			request.Service,
+		))
+	}
+
+	request.Service = current.Service
+	request.Namespace = current.Namespace
+	request.Cluster = current.Cluster
+	if request.Version == "" {
+		request.Version = current.Version
+	}
+
+	if !ctx.hasService(request.Service) {
+		logger.Warn("service not found", zap.String("service", request.Service))
+
+		return nil, ctx.reject(request, models.ErrServiceNotFound)
+	}
+
+	if err = ctx.authorize(job.Action, request); err != nil {
+		return nil, err
+	}
+
+	deployed, err := ctx.prepare(background, &request, current)
+	if err != nil {
+		return nil, ctx.reject(request, err)
+	}
+
+	if err = ctx.admission.Admit(job.Action, request, current, deployed); err != nil {
+		ctx.audit.Record(job.Action, request, err)
+
+		return nil, ctx.reject(request, err)
+	}
+
+	ctx.jobs.Run(job)
+
+	application, err := ctx.argocd.Update(background, request)
+	ctx.history.RecordOperation(job.Action, request, err)
+	ctx.audit.Record(job.Action, request, err)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.syncOverride(background, request)
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventUpgraded, request, "application upgraded"),
+	}
+
+	return application, nil
+}
+
+func (ctx *DeploymentManager) Delete(background context.Context, request models.DeploymentRequest) error {
+	request.Instance = strings.ToLower(request.Instance)
+
+	ctx.history.RecordCommand("delete", request)
+
+	if ctx.freeze.Enabled() {
+		planned, _, err := ctx.resolve(background, request)
+		if err != nil {
+			return err
+		}
+
+		if err = ctx.checkFreeze("delete", planned, 0); err != nil {
+			return err
+		}
+	}
+
+	job := ctx.jobs.Begin("delete", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		ctx.jobs.Finish(job, err)
+
+		return err
+	}
+	defer release()
+
+	err = ctx.delete(background, job, request)
+	ctx.jobs.Finish(job, err)
+
+	return err
+}
+
+func (ctx *DeploymentManager) delete(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+) error {
+	current, err := ctx.argocd.Get(background, request.Instance)
+	if err != nil {
+		return err
+	}
+
+	request = models.DeploymentRequest{
+		Job:       job.ID,
+		Service:   current.Service,
+		Instance:  current.Instance,
+		Version:   current.Version,
+		Namespace: current.Namespace,
+		Cluster:   current.Cluster,
+		Requester: request.Requester,
+		Override:  request.Override,
+	}
+
+	if err = ctx.authorize("delete", request); err != nil {
+		return err
+	}
+
+	deployed, err := ctx.argocd.GetList(background)
+	if err != nil {
+		return err
+	}
+
+	if dependents := ctx.findDependents(*current, deployed); len(dependents) > 0 {
+		return ctx.reject(request, models.NewError(
+			models.ErrDependentsDeployed,
+			"application '%s' cannot be deleted because the following applications depend on it: %v",
+			current.Instance,
+			lo.Map(dependents, func(item models.Application, _ int) string { return item.Instance }),
+		))
+	}
+
+	ctx.jobs.Run(job)
+
+	err = ctx.argocd.Delete(background, current.Instance)
+	ctx.history.RecordOperation("delete", request, err)
+	ctx.audit.Record("delete", request, err)
+	if err != nil {
+		return err
+	}
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventDeleted, request, "application deleted"),
+	}
+
+	return nil
+}
+
+func (ctx *DeploymentManager) History(background context.Context, instance string) ([]models.Revision, error) {
+	application, err := ctx.Get(background, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.argocd.History(background, application.Instance)
+}
+
+func (ctx *DeploymentManager) Rollback(
+	background context.Context,
+	request models.DeploymentRequest,
+	revision int64,
+) (*models.Application, error) {
+	request.Instance = strings.ToLower(request.Instance)
+
+	ctx.history.RecordCommand("rollback", request)
+
+	if ctx.freeze.Enabled() {
+		planned, _, err := ctx.resolve(background, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = ctx.checkFreeze("rollback", planned, revision); err != nil {
+			return nil, err
+		}
+	}
+
+	job := ctx.jobs.Begin("rollback", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		return ctx.track(job, nil, err)
+	}
+	defer release()
+
+	application, err := ctx.rollback(background, job, request, revision)
+
+	return ctx.track(job, application, err)
+}
+
+func (ctx *DeploymentManager) rollback(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+	revision int64,
+) (*models.Application, error) {
+	history, err := ctx.argocd.History(background, request.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	target, ok := lo.Find(history, func(item models.Revision) bool {
+		return item.ID == revision
+	})
+	if revision == 0 && len(history) > 1 {
+		target, ok = history[len(history)-2], true
+	}
+	if !ok {
+		return nil, ctx.reject(request, models.NewError(
+			models.ErrApplicationNotFound,
+			"revision %d of application '%s' not found",
+			revision,
+			request.Instance,
+		))
+	}
+
+	logger.Info("rolling back application", zap.String("instance", request.Instance), zap.Int64("revision", target.ID))
+
+	request.Version = target.Version
+	request.Values = target.Values
+	request.Replace = true
+
+	return ctx.upgrade(background, job, request)
+}
+
+func (ctx *DeploymentManager) Sync(
+	background context.Context,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	request.Instance = strings.ToLower(request.Instance)
+
+	ctx.history.RecordCommand("sync", request)
+
+	if ctx.freeze.Enabled() {
+		planned, _, err := ctx.resolve(background, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = ctx.checkFreeze("sync", planned, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	job := ctx.jobs.Begin("sync", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		return ctx.track(job, nil, err)
+	}
+	defer release()
+
+	application, err := ctx.sync(background, job, request)
+
+	return ctx.track(job, application, err)
+}
+
+func (ctx *DeploymentManager) sync(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	current, err := ctx.argocd.Get(background, request.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Service = current.Service
+	request.Namespace = current.Namespace
+	request.Cluster = current.Cluster
+	request.Version = current.Version
+
+	if err = ctx.authorize("sync", request); err != nil {
+		return nil, err
+	}
+
+	ctx.jobs.Run(job)
+
+	err = ctx.argocd.Sync(background, request.Instance)
+	ctx.history.RecordOperation("sync", request, err)
+	ctx.audit.Record("sync", request, err)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventSyncing, request, "application sync requested"),
+	}
+
+	return current, nil
+}
+
+func (ctx *DeploymentManager) Graph(background context.Context) (*models.DependencyGraph, error) {
+	deployed, err := ctx.argocd.GetList(background)
+	if err != nil {
+		return nil, err
+	}
+
+	requester := models.RequesterFrom(background)
+	deployed = lo.Filter(deployed, func(item models.Application, _ int) bool {
+		return ctx.authorizer.Authorize("fetch", fetchRequest(item, requester)) == nil
+	})
+
+	return &models.DependencyGraph{
+		Nodes: lo.Map(ctx.services, func(item config.ServiceConfig, _ int) models.DependencyNode {
+			service := strings.ToLower(item.Name)
+
+			return models.DependencyNode{
+				Service: service,
+				Version: item.Version,
+				Depends: lo.Map(item.Depends, func(depend config.ServiceDependConfig, _ int) models.Application {
+					return models.Application{
+						Name:    depend.Name,
+						Service: depend.Name,
+						Version: depend.Version,
+					}
+				}),
+				Instances: lo.Filter(deployed, func(application models.Application, _ int) bool {
+					return application.Service == service
+				}),
+			}
+		}),
+	}, nil
+}
+
+func (ctx *DeploymentManager) Adopt(
+	background context.Context,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	if request.Instance == "" {
+		request.Instance = request.Service
+	}
+	request.Instance = strings.ToLower(request.Instance)
+
+	ctx.history.RecordCommand("adopt", request)
+
+	job := ctx.jobs.Begin("adopt", &request)
+
+	release, err := ctx.acquire(background, request)
+	if err != nil {
+		ctx.jobs.Finish(job, err)
+
+		return nil, err
+	}
+	defer release()
+
+	application, err := ctx.adopt(background, job, request)
+	ctx.jobs.Finish(job, err)
+	if err != nil {
+		return nil, err
+	}
+
+	application.Job = job.ID
+
+	return application, nil
+}
+
+func (ctx *DeploymentManager) adopt(
+	background context.Context,
+	job *models.Job,
+	request models.DeploymentRequest,
+) (*models.Application, error) {
+	if !ctx.hasService(request.Service) {
+		logger.Warn("service not found", zap.String("service", request.Service))
+
+		return nil, ctx.reject(request, models.ErrServiceNotFound)
+	}
+
+	if err := ctx.authorize("adopt", request); err != nil {
+		return nil, err
+	}
+
+	ctx.jobs.Run(job)
+
+	application, err := ctx.argocd.Adopt(background, request)
+	ctx.history.RecordOperation("adopt", request, err)
+	ctx.audit.Record("adopt", request, err)
+	if err != nil {
+		return nil, ctx.reject(request, err)
+	}
+
+	ctx.events <- &models.SystemMessage{
+		Key: models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventAdopted, models.DeploymentRequest{
+			Job:       job.ID,
+			Service:   application.Service,
+			Instance:  application.Instance,
+			Version:   application.Version,
+			Namespace: application.Namespace,
+			Cluster:   application.Cluster,
+		}, "application adopted"),
+	}
+
+	return application, nil
+}
+
+func (ctx *DeploymentManager) Approve(
+	background context.Context,
+	id string,
+	voter models.Identity,
+	comment string,
+) (*models.Approval, error) {
+	approval, err := ctx.vote(id, voter, true, comment)
+	if err != nil || approval.State != models.ApprovalStateApproved {
+		return approval, err
+	}
+
+	logger.Info("executing approved deployment", zap.String("approval", approval.ID), zap.String("action", approval.Action))
+
+	switch approval.Action {
+	case "create":
+		_, err = ctx.Create(background, approval.Request)
+	case "upgrade":
+		_, err = ctx.Upgrade(background, approval.Request)
+	default:
+		ctx.approvals.Complete(approval, "", models.NewError(
+			models.ErrApprovalClosed,
+			"approval '%s' has unsupported action '%s'",
+			id,
+			approval.Action,
+		))
+
+		return approval, nil
+	}
+	if errors.Is(err, models.ErrDeploymentDeferred) {
+		logger.Info("approved deployment deferred", zap.String("approval", approval.ID), zap.Error(err))
+	}
+
+	return ctx.approvals.Get(id)
+}
+
+func (ctx *DeploymentManager) Reject(
+	_ context.Context,
+	id string,
+	voter models.Identity,
+	comment string,
+) (*models.Approval, error) {
+	return ctx.vote(id, voter, false, comment)
+}
+
+func (ctx *DeploymentManager) vote(id string, voter models.Identity, approve bool, comment string) (*models.Approval, error) {
+	action := lo.Ternary(approve, "approve", "reject")
+
+	approval, err := ctx.approvals.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	request := approval.Request
+	request.Requester = voter
+
+	if err = ctx.authorize(action, request); err != nil {
+		return nil, err
+	}
+
+	approval, err = ctx.approvals.Vote(id, voter, approve, comment)
+	ctx.audit.Record(action, request, err)
+
+	return approval, err
+}
+
+func (ctx *DeploymentManager) requireApproval(
+	action string,
+	request models.DeploymentRequest,
+	fromVersion string,
+) (*models.Approval, error) {
+	if request.Approval != "" {
+		return ctx.approvals.Admit(request.Approval, action, request)
+	}
+
+	if !ctx.approvals.Protected(request.Namespace) {
+		return nil, nil
+	}
+
+	if err := ctx.authorize(action, request); err != nil {
+		return nil, err
+	}
+
+	approval, err := ctx.approvals.Open(action, request, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, &models.ApprovalPendingError{Approval: approval}
+}
+
+func (ctx *DeploymentManager) complete(approval *models.Approval, application *models.Application, err error) {
+	if approval == nil {
+		return
+	}
+
+	job := ""
+	if application != nil {
+		job = application.Job
+	}
+	ctx.approvals.Complete(approval, job, err)
+}
+
+func (ctx *DeploymentManager) checkFreeze(action string, request models.DeploymentRequest, revision int64) error {
+	window := ctx.freeze.Active(request)
+	if window == nil {
+		return nil
+	}
+
+	if request.Override {
+		return ctx.override(action, request, *window)
+	}
+
+	if err := ctx.authorize(action, request); err != nil {
+		return err
+	}
+
+	if window.Mode == models.FreezeModeQueue {
+		deferral, err := ctx.freeze.Defer(action, request, revision, *window)
+		if err != nil {
+			return err
+		}
+
+		return &models.DeferredError{Deferral: deferral}
+	}
+
+	err := &models.FreezeError{Window: *window}
+	ctx.audit.Record(action, request, err)
+
+	return ctx.reject(request, err)
+}
+
+func (ctx *DeploymentManager) override(action string, request models.DeploymentRequest, window models.FreezeWindow) error {
+	if !ctx.authorizer.Enabled() {
+		err := models.NewError(
+			models.ErrPermissionDenied,
+			"overriding freeze window '%s' requires authorization to be enabled",
+			window.Name,
 		)
+		ctx.audit.Record("override", request, err)
+
+		return ctx.reject(request, err)
+	}
+
+	if err := ctx.authorize("override", request); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf(
+		"%s overrode freeze window '%s' to %s '%s'",
+		principal(request.Requester),
+		window.Name,
+		action,
+		request.Instance,
+	)
+	ctx.audit.Notice("override", request, message)
+	metrics.FreezeOverrides.WithLabelValues(window.Name).Inc()
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventOverridden, request, message),
+	}
+
+	return nil
+}
+
+func (ctx *DeploymentManager) syncOverride(background context.Context, request models.DeploymentRequest) {
+	if !request.Override || ctx.freeze.Active(request) == nil {
+		return
+	}
+
+	if err := ctx.argocd.Sync(background, request.Instance); err != nil {
+		logger.Warn("failed to sync overridden application", zap.String("instance", request.Instance), zap.Error(err))
+	}
+}
+
+func (ctx *DeploymentManager) resolve(
+	background context.Context,
+	request models.DeploymentRequest,
+) (models.DeploymentRequest, string, error) {
+	current, err := ctx.argocd.Get(background, request.Instance)
+	if err != nil {
+		return request, "", err
+	}
+
+	planned := request
+	planned.Service = current.Service
+	planned.Namespace = current.Namespace
+	planned.Cluster = current.Cluster
+	planned.Version = lo.CoalesceOrEmpty(request.Version, current.Version)
+
+	return planned, current.Version, nil
+}
+
+func (ctx *DeploymentManager) acquire(background context.Context, request models.DeploymentRequest) (func(), error) {
+	started := time.Now()
+	queued := false
+
+	release, err := ctx.locks.Acquire(background, request.Instance, func(depth int) {
+		queued = true
+
+		event := models.NewStatusEvent(
+			models.StatusEventQueued,
+			request,
+			fmt.Sprintf("waiting for %d operation(s) on '%s'", depth, request.Instance),
+		)
+		event.QueueDepth = depth
 
 		ctx.events <- &models.SystemMessage{
-			Key: models.ArgocdApplicationStatus,
-			Value: map[string]interface{}{
-				"message": message,
-			},
+			Key:   models.ArgocdApplicationStatus,
+			Value: event,
 		}
+	})
+	if err != nil {
+		return nil, ctx.reject(request, err)
+	}
 
-		logger.Warn(message)
+	if queued {
+		event := models.NewStatusEvent(models.StatusEventStarted, request, "operation started")
+		event.Waited = time.Since(started).Seconds()
 
-		return nil, errors.New(message)
+		ctx.events <- &models.SystemMessage{
+			Key:   models.ArgocdApplicationStatus,
+			Value: event,
+		}
 	}
 
-	application, err := ctx.argocd.Create(service, version, namespace, values)
+	return release, nil
+}
+
+func (ctx *DeploymentManager) track(
+	job *models.Job,
+	application *models.Application,
+	err error,
+) (*models.Application, error) {
 	if err != nil {
+		ctx.jobs.Finish(job, err)
+
 		return nil, err
 	}
 
+	application.Job = job.ID
+	ctx.jobs.Watch(job, *application)
+
 	return application, nil
 }
 
+func (ctx *DeploymentManager) prepare(
+	background context.Context,
+	request *models.DeploymentRequest,
+	current *models.Application,
+) ([]models.Application, error) {
+	if err := ctx.checkCluster(request.Service, request.Cluster); err != nil {
+		return nil, err
+	}
+
+	deployed, err := ctx.resolveDependencies(background, *request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(models.StatusEventDependencies, *request, "dependencies satisfied"),
+	}
+
+	if current != nil && !request.Replace {
+		request.Values = lo.Assign(ctx.carriedValues(*current, deployed), request.Values)
+	}
+
+	values, err := ctx.renderValues(*request, deployed)
+	if err != nil {
+		return nil, models.NewError(models.ErrInvalidValues, "%s", err.Error())
+	}
+	request.Values = values
+
+	return deployed, ctx.validator.Validate(request.Service, request.Version, request.Values)
+}
+
+func (ctx *DeploymentManager) resolveDependencies(
+	background context.Context,
+	request models.DeploymentRequest,
+) (deployed []models.Application, err error) {
+	background, span := tracing.Start(
+		background,
+		"dependencies.resolve",
+		trace.SpanKindInternal,
+		attribute.String("deployment.service", request.Service),
+		attribute.String("deployment.cluster", request.Cluster),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	deployed, _ = ctx.argocd.GetList(background)
+	deployed = lo.Filter(deployed, func(item models.Application, _ int) bool {
+		return item.Cluster == request.Cluster
+	})
+
+	if depends := ctx.findDepends(request.Service, deployed); len(depends) > 0 {
+		metrics.DependencyCheckFailures.WithLabelValues(request.Service).Inc()
+
+		return nil, models.NewError(
+			models.ErrDependencyMissing,
+			"service '%s' cannot be installed on cluster '%s' because the following dependencies are missing: %v",
+			request.Service,
+			request.Cluster,
+			depends,
+		)
+	}
+
+	return deployed, nil
+}
+
+func (ctx *DeploymentManager) authorize(action string, request models.DeploymentRequest) error {
+	if err := ctx.authorizer.Authorize(action, request); err != nil {
+		ctx.audit.Record(action, request, err)
+
+		return ctx.reject(request, err)
+	}
+
+	return nil
+}
+
+func (ctx *DeploymentManager) reject(request models.DeploymentRequest, err error) error {
+	event := models.NewStatusEvent(models.StatusEventRejected, request, err.Error())
+	event.Violations = models.ViolationsOf(err)
+
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: event,
+	}
+
+	logger.Warn("deployment request rejected", zap.String("instance", request.Instance), zap.Error(err))
+
+	return err
+}
+
+// chartVersion is the version a create without one deploys, so the job knows
+// which revision to wait for.
+func (ctx *DeploymentManager) chartVersion(service string) string {
+	serviceConfig, _ := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
+		return strings.EqualFold(item.Name, service)
+	})
+
+	return serviceConfig.Version
+}
+
 func (ctx *DeploymentManager) hasService(service string) bool {
 	serviceNames := lo.Map(ctx.services, func(item config.ServiceConfig, _ int) string {
 		return strings.ToLower(item.Name)
@@ -93,14 +984,28 @@ func (ctx *DeploymentManager) hasService(service string) bool {
 	return lo.Contains(serviceNames, service)
 }
 
-func (ctx *DeploymentManager) findDepends(service string) []models.Application {
+func (ctx *DeploymentManager) checkCluster(service, cluster string) error {
+	if !lo.Contains(ctx.clusters, cluster) {
+		return models.NewError(models.ErrClusterNotAllowed, "cluster '%s' is not registered", cluster)
+	}
+
+	serviceConfig, _ := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
+		return strings.ToLower(item.Name) == service
+	})
+	if len(serviceConfig.Clusters) > 0 && !lo.Contains(serviceConfig.Clusters, cluster) {
+		return models.NewError(models.ErrClusterNotAllowed, "service '%s' is not allowed on cluster '%s'", service, cluster)
+	}
+
+	return nil
+}
+
+func (ctx *DeploymentManager) findDepends(service string, deployed []models.Application) []models.Application {
 	application, _ := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
 		return item.Name == service
 	})
 
-	deployedApplications, _ := ctx.argocd.GetList()
-	deployedApplicationNames := lo.Map(deployedApplications, func(item models.Application, _ int) string {
-		return item.Name
+	deployedApplicationNames := lo.Map(deployed, func(item models.Application, _ int) string {
+		return item.Service
 	})
 
 	return lo.FilterMap(application.Depends, func(item config.ServiceDependConfig, _ int) (models.Application, bool) {
@@ -110,3 +1015,44 @@ func (ctx *DeploymentManager) findDepends(service string) []models.Application {
 		}, !lo.Contains(deployedApplicationNames, item.Name)
 	})
 }
+
+func (ctx *DeploymentManager) findDependents(target models.Application, deployed []models.Application) []models.Application {
+	siblings := lo.Filter(deployed, func(item models.Application, _ int) bool {
+		return item.Service == target.Service && item.Cluster == target.Cluster && item.Instance != target.Instance
+	})
+	if len(siblings) > 0 {
+		return nil
+	}
+
+	return lo.Filter(deployed, func(item models.Application, _ int) bool {
+		if item.Cluster != target.Cluster {
+			return false
+		}
+
+		service, _ := lo.Find(ctx.services, func(serviceConfig config.ServiceConfig) bool {
+			return strings.ToLower(serviceConfig.Name) == item.Service
+		})
+
+		return lo.ContainsBy(service.Depends, func(depend config.ServiceDependConfig) bool {
+			return depend.Name == target.Service
+		})
+	})
+}
+
+func clusterNames(clusters []config.ClusterConfig) []string {
+	names := lo.Map(clusters, func(item config.ClusterConfig, _ int) string {
+		return item.Name
+	})
+
+	return lo.Uniq(append(names, models.DefaultCluster))
+}
+
+func fetchRequest(application models.Application, requester models.Identity) models.DeploymentRequest {
+	return models.DeploymentRequest{
+		Service:   application.Service,
+		Instance:  application.Instance,
+		Namespace: application.Namespace,
+		Cluster:   application.Cluster,
+		Requester: requester,
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/logger"
+)
+
+const subscriberBufferSize = 64
+
+type EventBroker struct {
+	mutex       sync.RWMutex
+	subscribers map[chan *models.SystemMessage]models.EventFilter
+	latest      map[string]*models.SystemMessage
+}
+
+func NewEventBroker() usecases.EventBroker {
+	return &EventBroker{
+		subscribers: make(map[chan *models.SystemMessage]models.EventFilter),
+		latest:      make(map[string]*models.SystemMessage),
+	}
+}
+
+func (ctx *EventBroker) Publish(message *models.SystemMessage) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if event, ok := message.Value.(*models.StatusEvent); ok && event.Instance != "" {
+		if event.Type == models.StatusEventDeleted {
+			delete(ctx.latest, event.Instance)
+		} else {
+			ctx.latest[event.Instance] = message
+		}
+	}
+
+	for subscriber, filter := range ctx.subscribers {
+		if !filter.Match(message) {
+			continue
+		}
+
+		select {
+		case subscriber <- message:
+		default:
+			logger.Warn("dropping event for slow subscriber", zap.String("key", message.Key.Value))
+		}
+	}
+}
+
+func (ctx *EventBroker) Subscribe(filter models.EventFilter) (<-chan *models.SystemMessage, func()) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	var replay []*models.SystemMessage
+	if filter.Replay {
+		for _, message := range ctx.latest {
+			if filter.Match(message) {
+				replay = append(replay, message)
+			}
+		}
+
+		sort.Slice(replay, func(i, j int) bool {
+			return replay[i].Value.(*models.StatusEvent).Time.Before(replay[j].Value.(*models.StatusEvent).Time)
+		})
+	}
+
+	subscriber := make(chan *models.SystemMessage, subscriberBufferSize+len(replay))
+	for _, message := range replay {
+		subscriber <- message
+	}
+
+	ctx.subscribers[subscriber] = filter
+
+	var once sync.Once
+
+	return subscriber, func() {
+		once.Do(func() {
+			ctx.mutex.Lock()
+			delete(ctx.subscribers, subscriber)
+			ctx.mutex.Unlock()
+
+			close(subscriber)
+		})
+	}
+}
@@ -1,34 +1,93 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"strings"
 	"tera/deployment/internal/domain/models"
 	"tera/deployment/internal/ports"
 	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
 	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/metrics"
+	"tera/deployment/pkg/tracing"
+	"time"
 )
 
-type EventProcessor struct {
-	manager  usecases.DeploymentManager
+var (
+	kafkaActions = []string{
+		"fetch", "create", "upgrade", "rollback", "delete", "sync", "graph", "adopt", "history", "job", "plan",
+		"approve", "reject", "approvals", "schedules", "cancel",
+	}
+	errUnknownAction = errors.New("unknown action")
+)
+
+const (
+	defaultConcurrency = 8
+	defaultMaxPending  = 256
+)
+
+type intake struct {
 	consumer ports.KafkaConsumer
-	producer ports.KafkaProducer
-	events   chan any
+}
+
+type EventProcessor struct {
+	manager    usecases.DeploymentManager
+	broker     usecases.EventBroker
+	history    usecases.History
+	jobs       usecases.JobTracker
+	planner    usecases.Reconciler
+	approvals  usecases.Approvals
+	scheduler  usecases.Scheduler
+	authorizer usecases.Authorizer
+	consumer   ports.KafkaConsumer
+	producer   ports.KafkaProducer
+	events     chan any
+	pool       *workerPool
 }
 
 func NewEventProcessor(
+	conf *config.Config,
 	events chan any,
 	manager usecases.DeploymentManager,
+	broker usecases.EventBroker,
+	history usecases.History,
+	jobs usecases.JobTracker,
+	planner usecases.Reconciler,
+	approvals usecases.Approvals,
+	scheduler usecases.Scheduler,
+	authorizer usecases.Authorizer,
 	consumer ports.KafkaConsumer,
 	producer ports.KafkaProducer,
 ) usecases.EventProcessor {
-	return &EventProcessor{
-		manager:  manager,
-		consumer: consumer,
-		producer: producer,
-		events:   events,
+	processor := &EventProcessor{
+		manager:    manager,
+		broker:     broker,
+		history:    history,
+		jobs:       jobs,
+		planner:    planner,
+		approvals:  approvals,
+		scheduler:  scheduler,
+		authorizer: authorizer,
+		consumer:   consumer,
+		producer:   producer,
+		events:     events,
 	}
+	metrics.EventsDepth(func() int { return len(events) })
+
+	processor.pool = newWorkerPool(
+		lo.Ternary(conf.Processor.Concurrency > 0, conf.Processor.Concurrency, defaultConcurrency),
+		lo.Ternary(conf.Processor.MaxPending > 0, conf.Processor.MaxPending, defaultMaxPending),
+		processor.pause,
+		processor.resume,
+	)
+
+	return processor
 }
 
 func (ctx *EventProcessor) Register() error {
@@ -45,58 +104,339 @@ func (ctx *EventProcessor) Register() error {
 	return nil
 }
 
+// Intake lets the leader alone consume commands, so every job, approval,
+// deferral and schedule is recorded in the leader's store.
+func (ctx *EventProcessor) Intake() usecases.LeaderDuty {
+	return &intake{consumer: ctx.consumer}
+}
+
 func (ctx *EventProcessor) Close() error {
 	if err := ctx.consumer.Close(); err != nil {
 		return err
 	}
 
+	ctx.pool.Close()
+
 	return nil
 }
 
 func (ctx *EventProcessor) process(data any) {
 	switch message := data.(type) {
 	case *models.KafkaMessage:
-		ctx.processKafkaMessage(message)
+		metrics.KafkaConsumed.WithLabelValues(actionLabel(message.Action)).Inc()
+
+		key := strings.ToLower(lo.CoalesceOrEmpty(
+			message.Instance,
+			models.InstanceName(message.Service, lo.CoalesceOrEmpty(message.Namespace, message.Service)),
+		))
+		if !ctx.pool.Submit(key, func() { ctx.handle(message) }) {
+			logger.Warn("processor closed, dropping message", zap.String("action", message.Action))
+		}
 	case *models.SystemMessage:
-		ctx.processSystemMessage(message)
+		if event, ok := message.Value.(*models.StatusEvent); ok {
+			ctx.history.RecordEvent(event)
+		}
+
+		ctx.broker.Publish(message)
+		ctx.processSystemMessage(context.Background(), message)
+	}
+}
+
+func (ctx *EventProcessor) handle(message *models.KafkaMessage) {
+	background, span := tracing.Start(
+		lo.Ternary(message.Context != nil, message.Context, context.Background()),
+		"command "+actionLabel(message.Action),
+		trace.SpanKindConsumer,
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("deployment.action", message.Action),
+		attribute.String("deployment.service", message.Service),
+		attribute.String("deployment.instance", message.Instance),
+	)
+
+	started := time.Now()
+	err := ctx.processKafkaMessage(background, message)
+	if errors.Is(err, models.ErrApprovalRequired) {
+		logger.Info("command awaiting approval", zap.String("action", message.Action), zap.Error(err))
+
+		err = nil
+	}
+	if errors.Is(err, models.ErrDeploymentDeferred) {
+		logger.Info("command deferred", zap.String("action", message.Action), zap.Error(err))
+
+		err = nil
 	}
+
+	tracing.End(span, err)
+
+	metrics.CommandDuration.
+		WithLabelValues(actionLabel(message.Action), lo.Ternary(err == nil, "success", "failure")).
+		Observe(time.Since(started).Seconds())
 }
 
-func (ctx *EventProcessor) processKafkaMessage(message *models.KafkaMessage) {
+func (ctx *EventProcessor) processKafkaMessage(background context.Context, message *models.KafkaMessage) error {
+	message.Requester.System = lo.CoalesceOrEmpty(message.Requester.System, models.SystemKafka)
+	background = models.WithRequester(background, message.Requester)
+	if !message.Internal && lo.Contains([]string{"create", "upgrade"}, strings.ToLower(message.Action)) {
+		message.Approval = ""
+	}
+
+	if !message.ExecuteAt.IsZero() || message.Cron != "" {
+		schedule, err := ctx.scheduler.Schedule(background, *message)
+		if err != nil {
+			logger.Error("failed to schedule command", zap.String("action", message.Action), zap.Error(err))
+			return err
+		}
+
+		ctx.processSystemMessage(background, &models.SystemMessage{
+			Key:   models.DeploymentSchedule,
+			Value: schedule,
+		})
+
+		return nil
+	}
+
 	switch strings.ToLower(message.Action) {
 	case "fetch":
-		applications, err := ctx.manager.GetList()
+		applications, err := ctx.manager.GetList(background)
 		if err != nil {
 			logger.Error("failed to fetch application list", zap.Error(err))
-			return
+			return err
 		}
 
 		logger.Info("events successfully processed", zap.Any("applications", applications))
+
+		return nil
 	case "create":
-		application, err := ctx.manager.Create(
-			message.Service,
-			message.Version,
-			message.Namespace,
-			message.Values,
-		)
+		application, err := ctx.manager.Create(background, models.DeploymentRequest{
+			Approval:  message.Approval,
+			Schedule:  message.Schedule,
+			Service:   message.Service,
+			Instance:  message.Instance,
+			Version:   message.Version,
+			Namespace: message.Namespace,
+			Cluster:   message.Cluster,
+			Requester: message.Requester,
+			Values:    message.Values,
+			Override:  message.Override,
+		})
 		if application != nil && err == nil {
 			logger.Info("application created", zap.Any("application", application))
 		}
+
+		return err
+	case "upgrade":
+		application, err := ctx.manager.Upgrade(background, models.DeploymentRequest{
+			Approval:  message.Approval,
+			Schedule:  message.Schedule,
+			Service:   message.Service,
+			Instance:  lo.CoalesceOrEmpty(message.Instance, message.Service),
+			Version:   message.Version,
+			Requester: message.Requester,
+			Values:    message.Values,
+			Replace:   message.Replace,
+			Override:  message.Override,
+		})
+		if application != nil && err == nil {
+			logger.Info("application upgraded", zap.Any("application", application))
+		}
+
+		return err
+	case "rollback":
+		application, err := ctx.manager.Rollback(background, models.DeploymentRequest{
+			Schedule:  message.Schedule,
+			Instance:  lo.CoalesceOrEmpty(message.Instance, message.Service),
+			Requester: message.Requester,
+			Override:  message.Override,
+		}, message.Revision)
+		if application != nil && err == nil {
+			logger.Info("application rolled back", zap.Any("application", application))
+		}
+
+		return err
+	case "delete":
+		err := ctx.manager.Delete(background, models.DeploymentRequest{
+			Schedule:  message.Schedule,
+			Instance:  lo.CoalesceOrEmpty(message.Instance, message.Service),
+			Requester: message.Requester,
+			Override:  message.Override,
+		})
+		if err != nil {
+			logger.Error("failed to delete application", zap.Error(err))
+		}
+
+		return err
+	case "sync":
+		application, err := ctx.manager.Sync(background, models.DeploymentRequest{
+			Schedule:  message.Schedule,
+			Instance:  lo.CoalesceOrEmpty(message.Instance, message.Service),
+			Requester: message.Requester,
+			Override:  message.Override,
+		})
+		if application != nil && err == nil {
+			logger.Info("application sync requested", zap.Any("application", application))
+		}
+
+		return err
+	case "graph":
+		graph, err := ctx.manager.Graph(background)
+		if err != nil {
+			logger.Error("failed to build dependency graph", zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.ArgocdApplicationGraph, graph)
+	case "adopt":
+		application, err := ctx.manager.Adopt(background, models.DeploymentRequest{
+			Service:   message.Service,
+			Instance:  message.Instance,
+			Requester: message.Requester,
+		})
+		if application != nil && err == nil {
+			logger.Info("application adopted", zap.Any("application", application))
+		}
+
+		return err
+	case "history":
+		records, err := ctx.history.Query(models.HistoryQuery{
+			Service:   message.Service,
+			Namespace: message.Namespace,
+			Result:    message.Result,
+			From:      message.From,
+			To:        message.To,
+			Limit:     message.Limit,
+		})
+		if err != nil {
+			logger.Error("failed to query deployment history", zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.DeploymentHistory, records)
+	case "job":
+		job, err := ctx.jobs.Get(message.Job)
+		if err != nil {
+			logger.Error("failed to get deployment job", zap.String("job", message.Job), zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.DeploymentJob, job)
+	case "approve", "reject":
+		decide := lo.Ternary(strings.ToLower(message.Action) == "approve", ctx.manager.Approve, ctx.manager.Reject)
+
+		approval, err := decide(background, message.Approval, message.Requester, message.Comment)
+		if err != nil {
+			logger.Error("failed to decide approval", zap.String("approval", message.Approval), zap.Error(err))
+			return err
+		}
+
+		ctx.processSystemMessage(background, &models.SystemMessage{
+			Key:   models.DeploymentApproval,
+			Value: approval,
+		})
+
+		return nil
+	case "approvals":
+		approvals, err := ctx.approvals.List(lo.CoalesceOrEmpty(message.Result, models.ApprovalStatePending))
+		if err != nil {
+			logger.Error("failed to list approvals", zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.DeploymentApproval, approvals)
+	case "schedules":
+		schedules, err := ctx.scheduler.List(lo.CoalesceOrEmpty(message.Result, models.ScheduleStateActive))
+		if err != nil {
+			logger.Error("failed to list schedules", zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.DeploymentSchedule, schedules)
+	case "cancel":
+		schedule, err := ctx.scheduler.Cancel(message.Schedule, message.Requester)
+		if err != nil {
+			logger.Error("failed to cancel schedule", zap.String("schedule", message.Schedule), zap.Error(err))
+			return err
+		}
+
+		ctx.processSystemMessage(background, &models.SystemMessage{
+			Key:   models.DeploymentSchedule,
+			Value: schedule,
+		})
+
+		return nil
+	case "plan":
+		plan, err := ctx.planner.Plan(background)
+		if err != nil {
+			logger.Error("failed to build reconcile plan", zap.Error(err))
+			return err
+		}
+
+		return ctx.reply(background, models.ReconcilePlan, plan)
 	default:
 		logger.Warn("unknown action", zap.String("action", message.Action))
+
+		return errUnknownAction
+	}
+}
+
+func (ctx *EventProcessor) pause() {
+	if err := ctx.consumer.Pause(); err != nil {
+		logger.Error("failed to pause consumer", zap.Error(err))
+	}
+}
+
+func (ctx *EventProcessor) resume() {
+	if err := ctx.consumer.Resume(); err != nil {
+		logger.Error("failed to resume consumer", zap.Error(err))
 	}
 }
 
-func (ctx *EventProcessor) processSystemMessage(message *models.SystemMessage) {
+// reply answers a read command with what its requester may fetch.
+func (ctx *EventProcessor) reply(background context.Context, key models.Key, value any) error {
+	requester := models.RequesterFrom(background)
+
+	visible, ok := ctx.authorizer.Visible(requester, value)
+	if !ok {
+		return models.NewError(models.ErrPermissionDenied, "%s is not allowed to fetch this %s", principal(requester), key.Value)
+	}
+
+	ctx.processSystemMessage(background, &models.SystemMessage{Key: key, Value: visible})
+
+	return nil
+}
+
+func (ctx *EventProcessor) processSystemMessage(background context.Context, message *models.SystemMessage) {
 	for idx := 0; idx < 3; idx++ {
-		if err := ctx.producer.Produce(message.Key, message.Value); err != nil {
+		if err := ctx.producer.Produce(background, message.Key, message.Value); err != nil {
+			metrics.KafkaFailed.WithLabelValues("produce", message.Key.Value).Inc()
+
 			logger.Error(
 				fmt.Sprintf("failed to produce message (try: %d)", idx),
 				zap.Any("message", message),
 				zap.Error(err),
 			)
+
+			continue
 		}
 
-		break
+		metrics.KafkaProduced.WithLabelValues(message.Key.Value).Inc()
+
+		return
 	}
 }
+
+func actionLabel(action string) string {
+	action = strings.ToLower(action)
+	if !lo.Contains(kafkaActions, action) {
+		return "unknown"
+	}
+
+	return action
+}
+
+func (ctx *intake) Start() {
+	_ = ctx.consumer.Subscribe()
+}
+
+func (ctx *intake) Stop() {
+	_ = ctx.consumer.Unsubscribe()
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const (
+	freezeReleaseInterval = 30 * time.Second
+	freezeSyncTimeout     = 30 * time.Second
+	maxFreezeOccurrences  = 1000
+)
+
+type freezeWindow struct {
+	config   config.FreezeWindowConfig
+	schedule cron.Schedule
+	mode     string
+}
+
+type Freeze struct {
+	argocd      ports.Argocd
+	storage     ports.Storage
+	events      chan<- any
+	windows     []freezeWindow
+	syncWindows bool
+	mirrored    []models.SyncWindow
+	deferrals   sync.Mutex
+	mutex       sync.Mutex
+	done        chan struct{}
+	group       sync.WaitGroup
+}
+
+func NewFreeze(conf *config.Config, events chan any, argocd ports.Argocd, storage ports.Storage) usecases.Freeze {
+	mode := lo.CoalesceOrEmpty(conf.Freeze.Mode, models.FreezeModeReject)
+
+	windows := lo.Map(conf.Freeze.Windows, func(item config.FreezeWindowConfig, _ int) freezeWindow {
+		window, err := newFreezeWindow(item, mode)
+		if err != nil {
+			logger.Error("invalid freeze window", zap.String("window", item.Name), zap.Error(err))
+
+			panic(err)
+		}
+
+		return window
+	})
+
+	return &Freeze{
+		argocd:      argocd,
+		storage:     storage,
+		events:      events,
+		windows:     windows,
+		syncWindows: conf.Freeze.SyncWindows,
+	}
+}
+
+func newFreezeWindow(item config.FreezeWindowConfig, mode string) (freezeWindow, error) {
+	item.Name = lo.CoalesceOrEmpty(item.Name, item.Schedule)
+	mode = lo.CoalesceOrEmpty(item.Mode, mode)
+
+	if !lo.Contains([]string{models.FreezeModeReject, models.FreezeModeQueue}, mode) {
+		return freezeWindow{}, fmt.Errorf("unknown freeze mode '%s'", mode)
+	}
+	if item.Duration <= 0 {
+		return freezeWindow{}, fmt.Errorf("freeze window '%s' needs a positive duration", item.Name)
+	}
+	if strings.HasPrefix(item.Schedule, "@every") {
+		return freezeWindow{}, fmt.Errorf("freeze window '%s' needs a calendar schedule", item.Name)
+	}
+
+	schedule, err := parseCron(item.Schedule, item.Timezone)
+	if err != nil {
+		return freezeWindow{}, err
+	}
+
+	return freezeWindow{
+		config:   item,
+		schedule: schedule,
+		mode:     mode,
+	}, nil
+}
+
+func (ctx *Freeze) Enabled() bool {
+	return len(ctx.windows) > 0
+}
+
+func (ctx *Freeze) Active(request models.DeploymentRequest) *models.FreezeWindow {
+	now := time.Now()
+
+	var active *models.FreezeWindow
+	for _, window := range ctx.windows {
+		if !window.matches(request) {
+			continue
+		}
+
+		status := window.status(now)
+		if !status.Active {
+			continue
+		}
+
+		if active == nil ||
+			(status.Mode == models.FreezeModeReject && active.Mode != models.FreezeModeReject) ||
+			(status.Mode == active.Mode && status.End.After(active.End)) {
+			active = &status
+		}
+	}
+
+	return active
+}
+
+func (ctx *Freeze) Windows() []models.FreezeWindow {
+	now := time.Now()
+
+	return lo.Map(ctx.windows, func(item freezeWindow, _ int) models.FreezeWindow {
+		return item.status(now)
+	})
+}
+
+// Defer reuses the pending deferral of the same request, so a command retried
+// during a freeze runs once when the window ends. Deferrals that are due are
+// being released and are not reused.
+func (ctx *Freeze) Defer(
+	action string,
+	request models.DeploymentRequest,
+	revision int64,
+	window models.FreezeWindow,
+) (*models.Deferral, error) {
+	ctx.deferrals.Lock()
+	defer ctx.deferrals.Unlock()
+
+	deferrals, err := ctx.storage.ListDeferrals()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if existing, ok := lo.Find(deferrals, func(item models.Deferral) bool {
+		return item.ReleaseAt.After(now) &&
+			item.Action == action &&
+			item.Revision == revision &&
+			item.Request.Same(request)
+	}); ok {
+		logger.Info("deployment already deferred", zap.String("deferral", existing.ID), zap.String("action", action))
+
+		return &existing, nil
+	}
+
+	deferral := &models.Deferral{
+		ID:        uuid.NewString(),
+		Action:    action,
+		Request:   request,
+		Revision:  revision,
+		Window:    window.Name,
+		ReleaseAt: window.End,
+		CreatedAt: now,
+	}
+	deferral.Request.Job = ""
+
+	if err := ctx.storage.SaveDeferral(*deferral); err != nil {
+		logger.Error("failed to save deferral", zap.String("deferral", deferral.ID), zap.Error(err))
+
+		return nil, err
+	}
+
+	logger.Info(
+		"deployment deferred by freeze window",
+		zap.String("deferral", deferral.ID),
+		zap.String("action", action),
+		zap.String("instance", request.Instance),
+		zap.String("window", window.Name),
+		zap.Time("release_at", deferral.ReleaseAt),
+	)
+
+	ctx.events <- &models.SystemMessage{
+		Key: models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(
+			models.StatusEventDeferred,
+			deferral.Request,
+			fmt.Sprintf("%s deferred by freeze window '%s' until %s", action, window.Name, window.End.Format(time.RFC3339)),
+		),
+	}
+
+	return deferral, nil
+}
+
+func (ctx *Freeze) Deferrals() ([]models.Deferral, error) {
+	deferrals, err := ctx.storage.ListDeferrals()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deferrals, func(i, j int) bool {
+		return deferrals[i].ReleaseAt.Before(deferrals[j].ReleaseAt)
+	})
+
+	return deferrals, nil
+}
+
+func (ctx *Freeze) Start() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done != nil {
+		return
+	}
+	ctx.done = make(chan struct{})
+
+	logger.Info("starting freeze windows", zap.Int("windows", len(ctx.windows)))
+
+	ctx.group.Add(1)
+	go ctx.run(ctx.done)
+}
+
+func (ctx *Freeze) Stop() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done == nil {
+		return
+	}
+	close(ctx.done)
+	ctx.done = nil
+
+	ctx.group.Wait()
+
+	logger.Info("stopped freeze windows")
+}
+
+func (ctx *Freeze) run(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(freezeReleaseInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx.mirror()
+		ctx.release()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// mirror updates the Argo CD windows whenever the applications they name
+// change, so applications created since the last run are frozen there too.
+func (ctx *Freeze) mirror() {
+	if !ctx.syncWindows {
+		return
+	}
+
+	background, cancel := context.WithTimeout(context.Background(), freezeSyncTimeout)
+	defer cancel()
+
+	applications, err := ctx.argocd.GetList(background)
+	if err != nil {
+		logger.Error("failed to list applications for the Argo CD sync windows", zap.Error(err))
+
+		return
+	}
+
+	windows := lo.FilterMap(ctx.windows, func(item freezeWindow, _ int) (models.SyncWindow, bool) {
+		return item.syncWindow(applications)
+	})
+	if ctx.mirrored != nil && reflect.DeepEqual(windows, ctx.mirrored) {
+		return
+	}
+
+	if err = ctx.argocd.SyncWindows(background, windows); err != nil {
+		logger.Error("failed to mirror freeze windows to Argo CD", zap.Error(err))
+
+		return
+	}
+	ctx.mirrored = windows
+
+	logger.Info("mirrored freeze windows to Argo CD", zap.Int("windows", len(windows)))
+}
+
+// release hands a due command to the processor before it deletes the
+// deferral, so a restart in between runs the command again instead of losing
+// it.
+func (ctx *Freeze) release() {
+	deferrals, err := ctx.storage.ListDeferrals()
+	if err != nil {
+		logger.Error("failed to list deferrals", zap.Error(err))
+
+		return
+	}
+
+	now := time.Now()
+	for _, deferral := range deferrals {
+		if deferral.ReleaseAt.After(now) {
+			continue
+		}
+
+		if window := ctx.Active(deferral.Request); window != nil {
+			deferral.ReleaseAt = window.End
+			deferral.Window = window.Name
+			if err = ctx.storage.SaveDeferral(deferral); err != nil {
+				logger.Error("failed to save deferral", zap.String("deferral", deferral.ID), zap.Error(err))
+			}
+
+			continue
+		}
+
+		logger.Info(
+			"releasing deferred deployment",
+			zap.String("deferral", deferral.ID),
+			zap.String("action", deferral.Action),
+			zap.String("instance", deferral.Request.Instance),
+		)
+
+		ctx.events <- deferral.Command()
+
+		if err = ctx.storage.DeleteDeferral(deferral.ID); err != nil {
+			logger.Error("failed to delete deferral", zap.String("deferral", deferral.ID), zap.Error(err))
+		}
+	}
+}
+
+func (window *freezeWindow) matches(request models.DeploymentRequest) bool {
+	return allows(window.config.Services, request.Service) &&
+		allows(window.config.Namespaces, request.Namespace) &&
+		allows(window.config.Clusters, request.Cluster)
+}
+
+func (window *freezeWindow) status(now time.Time) models.FreezeWindow {
+	status := models.FreezeWindow{
+		Name:    window.config.Name,
+		Mode:    window.mode,
+		Message: window.config.Message,
+	}
+
+	duration := window.config.Duration
+	status.Start = window.schedule.Next(now.Add(-duration))
+	if status.Start.IsZero() {
+		return status
+	}
+	status.End = status.Start.Add(duration)
+	status.Active = !status.Start.After(now)
+
+	next := window.schedule.Next(status.Start)
+	for count := 0; !next.IsZero() && !next.After(status.End) && count < maxFreezeOccurrences; count++ {
+		status.End = next.Add(duration)
+		next = window.schedule.Next(next)
+	}
+
+	return status
+}
+
+// syncWindow mirrors the window to Argo CD, which applies a window to every
+// application matching any of its scopes. A window scoped by namespaces or by
+// clusters alone is mirrored as is; any other scope names the applications the
+// freeze covers today, and is left out while there are none.
+func (window *freezeWindow) syncWindow(applications []models.Application) (models.SyncWindow, bool) {
+	result := models.SyncWindow{
+		Schedule: window.config.Schedule,
+		Duration: window.config.Duration,
+		Timezone: window.config.Timezone,
+	}
+
+	services, namespaces, clusters := window.config.Services, window.config.Namespaces, window.config.Clusters
+	switch {
+	case len(services) == 0 && len(namespaces) == 0 && len(clusters) == 0:
+		result.Applications = []string{"*"}
+	case len(services) == 0 && len(clusters) == 0:
+		result.Namespaces = namespaces
+	case len(services) == 0 && len(namespaces) == 0:
+		result.Clusters = clusters
+	default:
+		result.Applications = lo.FilterMap(applications, func(item models.Application, _ int) (string, bool) {
+			return item.Instance, window.matches(models.DeploymentRequest{
+				Service:   item.Service,
+				Namespace: item.Namespace,
+				Cluster:   item.Cluster,
+			})
+		})
+		sort.Strings(result.Applications)
+	}
+
+	return result, len(result.Applications) > 0 || len(result.Namespaces) > 0 || len(result.Clusters) > 0
+}
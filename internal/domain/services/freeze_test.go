@@ -0,0 +1,267 @@
+package services
+
+import (
+	"reflect"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestFreezeWindowStatus(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weekend := config.FreezeWindowConfig{Name: "weekend", Schedule: "0 18 * * 5", Duration: 62 * time.Hour, Timezone: "Europe/Berlin"}
+	overlapping := config.FreezeWindowConfig{Name: "release", Schedule: "0 9,10 * * *", Duration: 90 * time.Minute}
+	morning := config.FreezeWindowConfig{Name: "morning", Schedule: "0 9 * * *", Duration: time.Hour, Timezone: "America/New_York"}
+
+	tests := []struct {
+		name   string
+		window config.FreezeWindowConfig
+		now    time.Time
+		active bool
+		start  time.Time
+		end    time.Time
+	}{
+		{
+			name:   "inside a weekend window",
+			window: weekend,
+			now:    time.Date(2026, 10, 17, 12, 0, 0, 0, berlin),
+			active: true,
+			start:  time.Date(2026, 10, 16, 18, 0, 0, 0, berlin),
+			end:    time.Date(2026, 10, 19, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:   "before a weekend window",
+			window: weekend,
+			now:    time.Date(2026, 10, 16, 17, 0, 0, 0, berlin),
+			start:  time.Date(2026, 10, 16, 18, 0, 0, 0, berlin),
+			end:    time.Date(2026, 10, 19, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:   "weekend window across the daylight saving change",
+			window: weekend,
+			now:    time.Date(2026, 3, 30, 8, 30, 0, 0, berlin),
+			active: true,
+			start:  time.Date(2026, 3, 27, 18, 0, 0, 0, berlin),
+			end:    time.Date(2026, 3, 30, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:   "first of overlapping occurrences",
+			window: overlapping,
+			now:    time.Date(2026, 10, 19, 9, 15, 0, 0, time.Local),
+			active: true,
+			start:  time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local),
+			end:    time.Date(2026, 10, 19, 11, 30, 0, 0, time.Local),
+		},
+		{
+			name:   "second of overlapping occurrences",
+			window: overlapping,
+			now:    time.Date(2026, 10, 19, 11, 0, 0, 0, time.Local),
+			active: true,
+			start:  time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local),
+			end:    time.Date(2026, 10, 19, 11, 30, 0, 0, time.Local),
+		},
+		{
+			name:   "after overlapping occurrences",
+			window: overlapping,
+			now:    time.Date(2026, 10, 19, 11, 30, 0, 0, time.Local),
+			start:  time.Date(2026, 10, 20, 9, 0, 0, 0, time.Local),
+			end:    time.Date(2026, 10, 20, 11, 30, 0, 0, time.Local),
+		},
+		{
+			name:   "window in another timezone during daylight saving time",
+			window: morning,
+			now:    time.Date(2026, 7, 1, 13, 30, 0, 0, time.UTC),
+			active: true,
+			start:  time.Date(2026, 7, 1, 9, 0, 0, 0, newYork),
+			end:    time.Date(2026, 7, 1, 10, 0, 0, 0, newYork),
+		},
+		{
+			name:   "window in another timezone during standard time",
+			window: morning,
+			now:    time.Date(2026, 1, 15, 13, 30, 0, 0, time.UTC),
+			start:  time.Date(2026, 1, 15, 9, 0, 0, 0, newYork),
+			end:    time.Date(2026, 1, 15, 10, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := newFreezeWindow(test.window, models.FreezeModeReject)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := window.status(test.now)
+			if status.Active != test.active || !status.Start.Equal(test.start) || !status.End.Equal(test.end) {
+				t.Fatalf(
+					"expected active=%v from %s to %s, got active=%v from %s to %s",
+					test.active, test.start, test.end, status.Active, status.Start, status.End,
+				)
+			}
+		})
+	}
+}
+
+func TestFreezeWindowSyncWindow(t *testing.T) {
+	applications := []models.Application{
+		{Instance: "api", Service: "api", Namespace: "api", Cluster: "eu"},
+		{Instance: "api-prod", Service: "api", Namespace: "prod", Cluster: "eu"},
+		{Instance: "api-gateway-prod", Service: "api-gateway", Namespace: "prod", Cluster: "eu"},
+		{Instance: "billing-prod", Service: "billing", Namespace: "prod", Cluster: "us"},
+	}
+
+	tests := []struct {
+		name     string
+		window   config.FreezeWindowConfig
+		expected models.SyncWindow
+		skipped  bool
+	}{
+		{
+			name:     "unscoped window",
+			window:   config.FreezeWindowConfig{},
+			expected: models.SyncWindow{Applications: []string{"*"}},
+		},
+		{
+			name:     "namespaces only",
+			window:   config.FreezeWindowConfig{Namespaces: []string{"prod"}},
+			expected: models.SyncWindow{Namespaces: []string{"prod"}},
+		},
+		{
+			name:     "clusters only",
+			window:   config.FreezeWindowConfig{Clusters: []string{"eu", "us"}},
+			expected: models.SyncWindow{Clusters: []string{"eu", "us"}},
+		},
+		{
+			name:     "service",
+			window:   config.FreezeWindowConfig{Services: []string{"api"}},
+			expected: models.SyncWindow{Applications: []string{"api", "api-prod"}},
+		},
+		{
+			name:     "service in a namespace",
+			window:   config.FreezeWindowConfig{Services: []string{"api"}, Namespaces: []string{"prod"}},
+			expected: models.SyncWindow{Applications: []string{"api-prod"}},
+		},
+		{
+			name:     "namespace in a cluster",
+			window:   config.FreezeWindowConfig{Namespaces: []string{"prod"}, Clusters: []string{"us"}},
+			expected: models.SyncWindow{Applications: []string{"billing-prod"}},
+		},
+		{
+			name:    "nothing deployed in scope",
+			window:  config.FreezeWindowConfig{Services: []string{"search"}},
+			skipped: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.window.Schedule = "0 18 * * 5"
+			test.window.Duration = time.Hour
+			test.expected.Schedule = test.window.Schedule
+			test.expected.Duration = test.window.Duration
+
+			window, err := newFreezeWindow(test.window, models.FreezeModeReject)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, ok := window.syncWindow(applications)
+			if test.skipped {
+				if ok {
+					t.Fatalf("expected the window to be left out, got %+v", result)
+				}
+
+				return
+			}
+
+			if !ok || !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFreezeDefer(t *testing.T) {
+	storage := newMemoryStorage()
+	freeze := NewFreeze(&config.Config{}, make(chan any, 16), nil, storage)
+	window := models.FreezeWindow{Name: "weekend", End: time.Now().Add(time.Hour)}
+	request := func(version string) models.DeploymentRequest {
+		return models.DeploymentRequest{Instance: "api", Version: version}
+	}
+
+	first, err := freeze.Defer("upgrade", request("1.1.0"), 0, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retried, err := freeze.Defer("upgrade", request("1.1.0"), 0, window)
+	if err != nil || retried.ID != first.ID {
+		t.Fatalf("expected deferral %s to be reused, got %+v (%v)", first.ID, retried, err)
+	}
+
+	values := request("1.1.0")
+	values.Values = map[string]string{"replicaCount": "3"}
+
+	for _, item := range []struct {
+		action   string
+		request  models.DeploymentRequest
+		revision int64
+	}{
+		{"upgrade", request("1.2.0"), 0},
+		{"upgrade", values, 0},
+		{"create", request("1.1.0"), 0},
+		{"rollback", request("1.1.0"), 3},
+	} {
+		other, err := freeze.Defer(item.action, item.request, item.revision, window)
+		if err != nil || other.ID == first.ID {
+			t.Fatalf("expected a new deferral for %+v, got %+v (%v)", item, other, err)
+		}
+	}
+
+	if deferrals, _ := storage.ListDeferrals(); len(deferrals) != 5 {
+		t.Fatalf("expected 5 deferrals, got %d", len(deferrals))
+	}
+}
+
+func TestFreezeRelease(t *testing.T) {
+	storage := newMemoryStorage()
+	events := make(chan any, 16)
+	freeze := NewFreeze(&config.Config{}, events, nil, storage).(*Freeze)
+
+	for id, releaseAt := range map[string]time.Time{
+		"due":    time.Now().Add(-time.Minute),
+		"frozen": time.Now().Add(time.Hour),
+	} {
+		deferral := models.Deferral{ID: id, Action: "upgrade", Request: models.DeploymentRequest{Instance: id}, ReleaseAt: releaseAt}
+		if err := storage.SaveDeferral(deferral); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	freeze.release()
+	close(events)
+
+	var commands []*models.KafkaMessage
+	for event := range events {
+		if command, ok := event.(*models.KafkaMessage); ok {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) != 1 || commands[0].Instance != "due" || !commands[0].Internal {
+		t.Fatalf("expected the due deferral to be released, got %+v", commands)
+	}
+
+	deferrals, _ := storage.ListDeferrals()
+	if len(deferrals) != 1 || deferrals[0].ID != "frozen" {
+		t.Fatalf("expected only the frozen deferral to remain, got %+v", deferrals)
+	}
+}
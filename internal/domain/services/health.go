@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const (
+	defaultHealthInterval   = 30 * time.Second
+	defaultHealthTimeout    = 5 * time.Second
+	defaultHealthSaturation = 0.9
+)
+
+type HealthChecker struct {
+	argocd   ports.Argocd
+	consumer ports.KafkaConsumer
+	producer ports.KafkaProducer
+	audit    usecases.Audit
+	events   chan any
+	config   config.HealthConfig
+	mutex    sync.Mutex
+	argo     models.HealthCheck
+	done     chan struct{}
+	group    sync.WaitGroup
+}
+
+func NewHealthChecker(
+	conf *config.Config,
+	events chan any,
+	argocd ports.Argocd,
+	consumer ports.KafkaConsumer,
+	producer ports.KafkaProducer,
+	audit usecases.Audit,
+) usecases.HealthChecker {
+	healthConfig := conf.Health
+	if healthConfig.Interval <= 0 {
+		healthConfig.Interval = defaultHealthInterval
+	}
+	if healthConfig.Timeout <= 0 {
+		healthConfig.Timeout = defaultHealthTimeout
+	}
+	if healthConfig.Saturation <= 0 || healthConfig.Saturation > 1 {
+		healthConfig.Saturation = defaultHealthSaturation
+	}
+
+	return &HealthChecker{
+		argocd:   argocd,
+		consumer: consumer,
+		producer: producer,
+		audit:    audit,
+		events:   events,
+		config:   healthConfig,
+		argo:     models.NewHealthCheck("argocd", fmt.Errorf("not checked yet")),
+	}
+}
+
+func (ctx *HealthChecker) Start() error {
+	ctx.done = make(chan struct{})
+
+	ctx.group.Add(1)
+	go ctx.loop(ctx.done)
+
+	return nil
+}
+
+func (ctx *HealthChecker) Close() error {
+	if ctx.done == nil {
+		return nil
+	}
+	close(ctx.done)
+
+	ctx.group.Wait()
+
+	return nil
+}
+
+func (ctx *HealthChecker) Liveness() models.HealthReport {
+	return healthReport(ctx.consumer.Alive())
+}
+
+func (ctx *HealthChecker) Readiness() models.HealthReport {
+	ctx.mutex.Lock()
+	argo := ctx.argo
+	ctx.mutex.Unlock()
+
+	return healthReport(
+		ctx.consumer.Health(),
+		ctx.producer.Health(),
+		argo,
+		ctx.audit.Health(),
+		ctx.saturation(),
+	)
+}
+
+func (ctx *HealthChecker) loop(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(ctx.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		ctx.ping()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ctx *HealthChecker) ping() {
+	background, cancel := context.WithTimeout(context.Background(), ctx.config.Timeout)
+	defer cancel()
+
+	check := models.NewHealthCheck("argocd", ctx.argocd.Ping(background))
+	if !check.Healthy {
+		logger.Warn("argocd health check failed", zap.String("message", check.Message))
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.argo = check
+}
+
+func (ctx *HealthChecker) saturation() models.HealthCheck {
+	depth, capacity := len(ctx.events), cap(ctx.events)
+	if capacity != 0 && float64(depth)/float64(capacity) >= ctx.config.Saturation {
+		return models.NewHealthCheck("events", fmt.Errorf("events channel saturated (%d/%d)", depth, capacity))
+	}
+
+	return models.NewHealthCheck("events", nil)
+}
+
+func healthReport(checks ...models.HealthCheck) models.HealthReport {
+	return models.HealthReport{
+		Healthy: lo.EveryBy(checks, func(item models.HealthCheck) bool {
+			return item.Healthy
+		}),
+		Checks: checks,
+	}
+}
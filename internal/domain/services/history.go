@@ -0,0 +1,104 @@
+package services
+
+import (
+	"go.uber.org/zap"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+type History struct {
+	storage ports.Storage
+	mutex   sync.Mutex
+	last    map[string]models.ApplicationStatus
+}
+
+func NewHistory(storage ports.Storage) usecases.History {
+	return &History{
+		storage: storage,
+		last:    make(map[string]models.ApplicationStatus),
+	}
+}
+
+func (ctx *History) RecordCommand(action string, request models.DeploymentRequest) {
+	ctx.append(historyRecord(models.HistoryKindCommand, action, request, models.HistoryResultReceived, ""))
+}
+
+func (ctx *History) RecordOperation(action string, request models.DeploymentRequest, err error) {
+	if err != nil {
+		ctx.append(historyRecord(models.HistoryKindOperation, action, request, models.HistoryResultFailed, err.Error()))
+		return
+	}
+
+	ctx.append(historyRecord(models.HistoryKindOperation, action, request, models.HistoryResultAccepted, ""))
+}
+
+func (ctx *History) RecordEvent(event *models.StatusEvent) {
+	if event.Type == models.StatusEventProgress && !ctx.transitioned(event) {
+		return
+	}
+	if event.Final() {
+		ctx.mutex.Lock()
+		delete(ctx.last, event.Instance)
+		ctx.mutex.Unlock()
+	}
+
+	ctx.append(models.HistoryRecord{
+		Time:      event.Time,
+		Kind:      models.HistoryKindStatus,
+		Action:    event.Type,
+		Service:   event.Service,
+		Instance:  event.Instance,
+		Namespace: event.Namespace,
+		Cluster:   event.Cluster,
+		Version:   event.Version,
+		Result:    event.Type,
+		Message:   event.Message,
+		Status:    event.Status,
+	})
+}
+
+func (ctx *History) Query(query models.HistoryQuery) ([]models.HistoryRecord, error) {
+	return ctx.storage.QueryHistory(query)
+}
+
+func (ctx *History) transitioned(event *models.StatusEvent) bool {
+	if event.Status == nil {
+		return false
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if last, ok := ctx.last[event.Instance]; ok && last == *event.Status {
+		return false
+	}
+	ctx.last[event.Instance] = *event.Status
+
+	return true
+}
+
+func (ctx *History) append(record models.HistoryRecord) {
+	if err := ctx.storage.AppendHistory(record); err != nil {
+		logger.Error("failed to append history record", zap.Any("record", record), zap.Error(err))
+	}
+}
+
+func historyRecord(kind, action string, request models.DeploymentRequest, result, message string) models.HistoryRecord {
+	return models.HistoryRecord{
+		Time:      time.Now(),
+		Kind:      kind,
+		Action:    action,
+		Requester: request.Requester,
+		Service:   request.Service,
+		Instance:  request.Instance,
+		Namespace: request.Namespace,
+		Cluster:   request.Cluster,
+		Version:   request.Version,
+		Result:    result,
+		Message:   message,
+	}
+}
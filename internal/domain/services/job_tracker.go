@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/metrics"
+	"time"
+)
+
+const (
+	defaultSyncTimeout = 3 * time.Minute
+	defaultRetention   = 30 * 24 * time.Hour
+	syncPollInterval   = 5 * time.Second
+	pruneInterval      = time.Hour
+)
+
+type JobTracker struct {
+	argocd      ports.Argocd
+	storage     ports.Storage
+	events      chan<- any
+	syncTimeout time.Duration
+	retention   time.Duration
+	mutex       sync.Mutex
+	done        chan struct{}
+	group       sync.WaitGroup
+}
+
+func NewJobTracker(
+	conf *config.Config,
+	events chan any,
+	argocd ports.Argocd,
+	storage ports.Storage,
+) usecases.JobTracker {
+	syncTimeout := conf.Jobs.SyncTimeout
+	if syncTimeout <= 0 {
+		syncTimeout = defaultSyncTimeout
+	}
+
+	return &JobTracker{
+		argocd:      argocd,
+		storage:     storage,
+		events:      events,
+		syncTimeout: syncTimeout,
+		retention:   lo.Ternary(conf.Storage.Retention > 0, conf.Storage.Retention, defaultRetention),
+	}
+}
+
+func (ctx *JobTracker) Begin(action string, request *models.DeploymentRequest) *models.Job {
+	now := time.Now()
+
+	job := &models.Job{
+		ID:        uuid.NewString(),
+		Action:    action,
+		State:     models.JobStatePending,
+		Service:   request.Service,
+		Instance:  request.Instance,
+		Namespace: request.Namespace,
+		Cluster:   request.Cluster,
+		Version:   request.Version,
+		Requester: request.Requester,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	request.Job = job.ID
+
+	ctx.save(job)
+
+	return job
+}
+
+func (ctx *JobTracker) Run(job *models.Job) {
+	ctx.transition(job, models.JobStateRunning, "")
+}
+
+func (ctx *JobTracker) Watch(job *models.Job, application models.Application) {
+	job.Service = application.Service
+	job.Namespace = application.Namespace
+	job.Cluster = application.Cluster
+	job.Version = application.Version
+	job.Deadline = time.Now().Add(ctx.syncTimeout)
+
+	ctx.transition(job, models.JobStateWaitingForSync, "waiting for application sync")
+}
+
+func (ctx *JobTracker) Finish(job *models.Job, err error) {
+	if err != nil {
+		ctx.transition(job, models.JobStateFailed, err.Error())
+		return
+	}
+
+	ctx.transition(job, models.JobStateSucceeded, "")
+}
+
+func (ctx *JobTracker) Get(id string) (*models.Job, error) {
+	return ctx.storage.GetJob(id)
+}
+
+func (ctx *JobTracker) Start() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done != nil {
+		return
+	}
+	ctx.done = make(chan struct{})
+
+	logger.Info("starting job monitor")
+
+	ctx.resume()
+
+	ctx.group.Add(1)
+	go ctx.monitor(ctx.done)
+}
+
+func (ctx *JobTracker) Stop() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done == nil {
+		return
+	}
+	close(ctx.done)
+	ctx.done = nil
+
+	ctx.group.Wait()
+
+	logger.Info("stopped job monitor")
+}
+
+// prune keeps the store bounded by dropping finished jobs and history records
+// past the retention.
+func (ctx *JobTracker) prune() {
+	if err := ctx.storage.Prune(time.Now().Add(-ctx.retention)); err != nil {
+		logger.Error("failed to prune storage", zap.Error(err))
+	}
+}
+
+func (ctx *JobTracker) resume() {
+	jobs, err := ctx.storage.ListActiveJobs()
+	if err != nil {
+		logger.Error("failed to list active jobs", zap.Error(err))
+
+		return
+	}
+
+	for _, item := range jobs {
+		job := item
+
+		if job.State != models.JobStateWaitingForSync {
+			ctx.transition(&job, models.JobStateFailed, "interrupted by a server restart")
+		}
+	}
+}
+
+func (ctx *JobTracker) monitor(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	ctx.prune()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-prune.C:
+			ctx.prune()
+		case <-ticker.C:
+			jobs, err := ctx.storage.ListActiveJobs()
+			if err != nil {
+				logger.Error("failed to list active jobs", zap.Error(err))
+				continue
+			}
+
+			waiting := lo.Filter(jobs, func(item models.Job, _ int) bool {
+				return item.State == models.JobStateWaitingForSync
+			})
+			metrics.SyncTrackers.Set(float64(len(waiting)))
+
+			for _, item := range waiting {
+				job := item
+				ctx.check(context.Background(), &job)
+			}
+		}
+	}
+}
+
+func (ctx *JobTracker) check(background context.Context, job *models.Job) {
+	if time.Now().After(job.Deadline) {
+		ctx.transition(job, models.JobStateTimedOut, "timed out waiting for application sync")
+		ctx.publish(models.NewStatusEvent(models.StatusEventFailed, job.Request(), job.Message))
+
+		return
+	}
+
+	application, err := ctx.argocd.Get(background, job.Instance)
+	if errors.Is(err, models.ErrApplicationNotFound) {
+		ctx.transition(job, models.JobStateFailed, "application no longer exists")
+		ctx.publish(models.NewStatusEvent(models.StatusEventFailed, job.Request(), job.Message))
+
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get Argocd application", zap.String("job", job.ID), zap.Error(err))
+
+		ctx.publish(models.NewStatusEvent(models.StatusEventProgress, job.Request(), "failed to get Argocd application"))
+
+		return
+	}
+
+	logger.Info(
+		"Argocd application status",
+		zap.String("job", job.ID),
+		zap.String("status", application.Status.Sync),
+		zap.String("healthStatus", application.Status.Health),
+		zap.String("revision", application.Status.Revision),
+	)
+
+	status := application.Status
+
+	event := models.NewStatusEvent(models.StatusEventProgress, job.Request(), "")
+	event.Status = &status
+
+	switch {
+	case status.Deployed(job.Version, job.CreatedAt):
+		event = models.NewStatusEvent(models.StatusEventSucceeded, job.Request(), "application synced and healthy")
+		event.Status = &status
+
+		job.Status = &status
+		ctx.transition(job, models.JobStateSucceeded, event.Message)
+		metrics.TimeToHealthy.WithLabelValues(job.Service).Observe(time.Since(job.CreatedAt).Seconds())
+
+		logger.Info("Argocd application synced", zap.String("job", job.ID))
+	case job.Status == nil || *job.Status != status:
+		job.Status = &status
+		ctx.transition(job, models.JobStateWaitingForSync, job.Message)
+	}
+
+	ctx.publish(event)
+}
+
+func (ctx *JobTracker) transition(job *models.Job, state, message string) {
+	job.State = state
+	job.Message = message
+	job.UpdatedAt = time.Now()
+
+	ctx.save(job)
+}
+
+func (ctx *JobTracker) save(job *models.Job) {
+	if err := ctx.storage.SaveJob(*job); err != nil {
+		logger.Error("failed to save job", zap.String("job", job.ID), zap.Error(err))
+	}
+}
+
+func (ctx *JobTracker) publish(event *models.StatusEvent) {
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: event,
+	}
+}
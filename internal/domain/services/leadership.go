@@ -0,0 +1,78 @@
+package services
+
+import (
+	"go.uber.org/zap"
+	"sync"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/logger"
+)
+
+// Leadership runs the duties on the elected leader only. The leader is also the
+// only replica that consumes commands and serves the API, so the jobs,
+// approvals, deferrals and schedules in its store are the complete set.
+type Leadership struct {
+	elector ports.LeaderElector
+	duties  []usecases.LeaderDuty
+	mutex   sync.Mutex
+	leader  bool
+}
+
+func NewLeadership(elector ports.LeaderElector, duties []usecases.LeaderDuty) usecases.Leadership {
+	return &Leadership{
+		elector: elector,
+		duties:  duties,
+	}
+}
+
+func (ctx *Leadership) Start() error {
+	return ctx.elector.Run(ctx.started, ctx.stopped)
+}
+
+func (ctx *Leadership) Close() error {
+	return ctx.elector.Close()
+}
+
+func (ctx *Leadership) IsLeader() bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.leader
+}
+
+// Leader names the current leader, or is empty while none is known.
+func (ctx *Leadership) Leader() string {
+	return ctx.elector.Leader()
+}
+
+func (ctx *Leadership) started() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.leader {
+		return
+	}
+	ctx.leader = true
+
+	logger.Info("acquired leadership", zap.Int("duties", len(ctx.duties)))
+
+	for _, duty := range ctx.duties {
+		duty.Start()
+	}
+}
+
+func (ctx *Leadership) stopped() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if !ctx.leader {
+		return
+	}
+	ctx.leader = false
+
+	logger.Info("released leadership")
+
+	for idx := len(ctx.duties) - 1; idx >= 0; idx-- {
+		ctx.duties[idx].Stop()
+	}
+}
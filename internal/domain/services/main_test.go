@@ -0,0 +1,14 @@
+package services
+
+import (
+	"os"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(&config.Config{Logging: config.LoggingConfig{Level: "error"}})
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"time"
+)
+
+const (
+	busyModeQueue       = "queue"
+	busyModeReject      = "reject"
+	defaultQueueTimeout = 15 * time.Minute
+)
+
+// OperationLock serializes operations per instance within this process; with
+// leader election only the leader runs operations, followers forward to it.
+type OperationLock struct {
+	mutex   sync.Mutex
+	queues  map[string]*operationQueue
+	reject  bool
+	timeout time.Duration
+}
+
+type operationQueue struct {
+	held    bool
+	waiters []chan struct{}
+}
+
+func NewOperationLock(conf *config.Config) usecases.OperationLock {
+	timeout := conf.Operations.QueueTimeout
+	if timeout <= 0 {
+		timeout = defaultQueueTimeout
+	}
+
+	return &OperationLock{
+		queues:  make(map[string]*operationQueue),
+		reject:  strings.ToLower(conf.Operations.Busy) == busyModeReject,
+		timeout: timeout,
+	}
+}
+
+// Acquire hands the lock to waiters in arrival order; a waiter gives up when
+// background is done or after the queue timeout.
+func (ctx *OperationLock) Acquire(background context.Context, key string, queued func(depth int)) (func(), error) {
+	ctx.mutex.Lock()
+
+	queue, ok := ctx.queues[key]
+	if !ok {
+		queue = &operationQueue{}
+		ctx.queues[key] = queue
+	}
+
+	if !queue.held {
+		queue.held = true
+		ctx.mutex.Unlock()
+
+		return ctx.release(key), nil
+	}
+
+	if ctx.reject {
+		ctx.mutex.Unlock()
+
+		return nil, models.NewError(models.ErrOperationInProgress, "an operation on '%s' is already in progress", key)
+	}
+
+	turn := make(chan struct{})
+	queue.waiters = append(queue.waiters, turn)
+	depth := len(queue.waiters)
+	ctx.mutex.Unlock()
+
+	if queued != nil {
+		queued(depth)
+	}
+
+	timer := time.NewTimer(ctx.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-turn:
+		return ctx.release(key), nil
+	case <-background.Done():
+		return nil, ctx.abandon(key, turn, background.Err())
+	case <-timer.C:
+		return nil, ctx.abandon(key, turn, fmt.Errorf("timed out after %s", ctx.timeout))
+	}
+}
+
+// abandon takes a waiter out of the queue, passing the lock on when it was
+// handed over while the waiter gave up.
+func (ctx *OperationLock) abandon(key string, turn chan struct{}, cause error) error {
+	ctx.mutex.Lock()
+	queue := ctx.queues[key]
+	index := slices.Index(queue.waiters, turn)
+	if index >= 0 {
+		queue.waiters = slices.Delete(queue.waiters, index, index+1)
+	}
+	ctx.mutex.Unlock()
+
+	if index < 0 {
+		ctx.release(key)()
+	}
+
+	return models.NewError(
+		models.ErrOperationInProgress,
+		"gave up waiting for the operation on '%s' in progress: %s",
+		key,
+		cause,
+	)
+}
+
+func (ctx *OperationLock) release(key string) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			ctx.mutex.Lock()
+			defer ctx.mutex.Unlock()
+
+			queue := ctx.queues[key]
+			if len(queue.waiters) == 0 {
+				delete(ctx.queues, key)
+				return
+			}
+
+			next := queue.waiters[0]
+			queue.waiters = queue.waiters[1:]
+			close(next)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestOperationLockOrder(t *testing.T) {
+	lock := NewOperationLock(&config.Config{})
+
+	release, err := lock.Acquire(context.Background(), "api", nil)
+	if err != nil {
+		t.Fatalf("expected the free lock to be acquired, got %v", err)
+	}
+
+	var (
+		mutex sync.Mutex
+		order []int
+		group sync.WaitGroup
+	)
+	for idx := range 5 {
+		queued := make(chan int, 1)
+
+		group.Add(1)
+		go func() {
+			defer group.Done()
+
+			release, err := lock.Acquire(context.Background(), "api", func(depth int) {
+				queued <- depth
+			})
+			if err != nil {
+				t.Errorf("waiter %d: %v", idx, err)
+				return
+			}
+
+			mutex.Lock()
+			order = append(order, idx)
+			mutex.Unlock()
+
+			release()
+		}()
+
+		if depth := <-queued; depth != idx+1 {
+			t.Fatalf("expected waiter %d at depth %d, got %d", idx, idx+1, depth)
+		}
+	}
+
+	release()
+	group.Wait()
+
+	for idx, item := range order {
+		if item != idx {
+			t.Fatalf("expected waiters to run in arrival order, got %v", order)
+		}
+	}
+	if len(order) != 5 {
+		t.Fatalf("expected 5 waiters to run, got %v", order)
+	}
+}
+
+func TestOperationLockReject(t *testing.T) {
+	lock := NewOperationLock(&config.Config{Operations: config.OperationsConfig{Busy: busyModeReject}})
+
+	release, err := lock.Acquire(context.Background(), "api", nil)
+	if err != nil {
+		t.Fatalf("expected the free lock to be acquired, got %v", err)
+	}
+
+	if _, err = lock.Acquire(context.Background(), "api", nil); !errors.Is(err, models.ErrOperationInProgress) {
+		t.Fatalf("expected a busy instance to be rejected, got %v", err)
+	}
+
+	other, err := lock.Acquire(context.Background(), "billing", nil)
+	if err != nil {
+		t.Fatalf("expected another instance to be acquired, got %v", err)
+	}
+	other()
+
+	release()
+	release()
+
+	if release, err = lock.Acquire(context.Background(), "api", nil); err != nil {
+		t.Fatalf("expected the released lock to be acquired, got %v", err)
+	}
+	release()
+}
+
+func TestOperationLockGiveUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+	}{
+		{
+			name:    "cancelled waiter",
+			timeout: time.Minute,
+			cancel:  true,
+		},
+		{
+			name:    "timed out waiter",
+			timeout: 10 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lock := NewOperationLock(&config.Config{Operations: config.OperationsConfig{QueueTimeout: test.timeout}})
+
+			release, err := lock.Acquire(context.Background(), "api", nil)
+			if err != nil {
+				t.Fatalf("expected the free lock to be acquired, got %v", err)
+			}
+
+			background, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			queued := make(chan struct{})
+			done := make(chan error, 1)
+			go func() {
+				_, err := lock.Acquire(background, "api", func(int) { close(queued) })
+				done <- err
+			}()
+
+			<-queued
+			if test.cancel {
+				cancel()
+			}
+
+			if err = <-done; !errors.Is(err, models.ErrOperationInProgress) {
+				t.Fatalf("expected the waiter to give up, got %v", err)
+			}
+
+			release()
+
+			acquired := make(chan struct{})
+			go func() {
+				release, err := lock.Acquire(context.Background(), "api", nil)
+				if err == nil {
+					release()
+				}
+				close(acquired)
+			}()
+
+			if !waitFor(func() bool {
+				select {
+				case <-acquired:
+					return true
+				default:
+					return false
+				}
+			}) {
+				t.Fatal("expected the lock to be free once the waiter gave up")
+			}
+		})
+	}
+}
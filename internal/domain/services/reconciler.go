@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+	"tera/deployment/pkg/tracing"
+	"time"
+)
+
+const (
+	defaultReconcileInterval = time.Minute
+	reconcilerRequester      = "reconciler"
+)
+
+type Reconciler struct {
+	argocd   ports.Argocd
+	manager  usecases.DeploymentManager
+	events   chan<- any
+	services []config.ServiceConfig
+	config   config.ReconcilerConfig
+	mutex    sync.Mutex
+	done     chan struct{}
+	group    sync.WaitGroup
+}
+
+type desiredState struct {
+	Applications []models.DesiredApplication `yaml:"applications"`
+}
+
+func NewReconciler(
+	conf *config.Config,
+	events chan any,
+	argocd ports.Argocd,
+	manager usecases.DeploymentManager,
+) usecases.Reconciler {
+	reconcilerConfig := conf.Reconciler
+	if reconcilerConfig.Interval <= 0 {
+		reconcilerConfig.Interval = defaultReconcileInterval
+	}
+
+	return &Reconciler{
+		argocd:   argocd,
+		manager:  manager,
+		events:   events,
+		services: conf.Services,
+		config:   reconcilerConfig,
+	}
+}
+
+func (ctx *Reconciler) Start() {
+	if !ctx.config.Enabled {
+		return
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done != nil {
+		return
+	}
+	ctx.done = make(chan struct{})
+
+	logger.Info(
+		"starting reconciler",
+		zap.Duration("interval", ctx.config.Interval),
+		zap.Bool("planOnly", ctx.config.PlanOnly),
+		zap.Bool("prune", ctx.config.Prune),
+	)
+
+	ctx.group.Add(1)
+	go ctx.loop(ctx.done)
+}
+
+func (ctx *Reconciler) Stop() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done == nil {
+		return
+	}
+	close(ctx.done)
+	ctx.done = nil
+
+	ctx.group.Wait()
+
+	logger.Info("stopped reconciler")
+}
+
+func (ctx *Reconciler) Plan(background context.Context) (*models.Plan, error) {
+	desired, err := ctx.desired()
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := ctx.argocd.GetList(background)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Plan{
+		Steps: ctx.plan(desired, deployed),
+		Time:  time.Now(),
+	}, nil
+}
+
+func (ctx *Reconciler) Reconcile(background context.Context) (plan *models.Plan, err error) {
+	background, span := tracing.Start(background, "reconcile", trace.SpanKindInternal)
+	defer func() { tracing.End(span, err) }()
+
+	plan, err = ctx.Plan(background)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan.Steps) == 0 {
+		return plan, nil
+	}
+
+	ctx.publish(plan)
+
+	if ctx.config.PlanOnly {
+		return plan, nil
+	}
+
+	ctx.apply(background, plan)
+	ctx.publish(plan)
+
+	return plan, nil
+}
+
+func (ctx *Reconciler) loop(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(ctx.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := ctx.Reconcile(context.Background()); err != nil {
+			logger.Error("failed to reconcile desired state", zap.Error(err))
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ctx *Reconciler) desired() ([]models.DesiredApplication, error) {
+	var desired []models.DesiredApplication
+
+	if ctx.config.File != "" {
+		data, err := os.ReadFile(ctx.config.File)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read desired state file")
+		}
+
+		var state desiredState
+		if err = yaml.Unmarshal(data, &state); err != nil {
+			return nil, errors.Wrap(err, "failed to parse desired state file")
+		}
+
+		desired = state.Applications
+	} else {
+		for _, service := range ctx.services {
+			for _, target := range service.Targets {
+				desired = append(desired, models.DesiredApplication{
+					Service:   service.Name,
+					Instance:  target.Instance,
+					Version:   target.Version,
+					Namespace: target.Namespace,
+					Cluster:   target.Cluster,
+					Values:    target.Values,
+				})
+			}
+		}
+	}
+
+	return lo.Map(desired, func(item models.DesiredApplication, _ int) models.DesiredApplication {
+		item.Service = strings.ToLower(item.Service)
+		if item.Namespace == "" {
+			item.Namespace = item.Service
+		}
+		if item.Cluster == "" {
+			item.Cluster = models.DefaultCluster
+		}
+		if item.Instance == "" {
+			item.Instance = models.InstanceName(item.Service, item.Namespace)
+		}
+		item.Instance = strings.ToLower(item.Instance)
+		if item.Version == "" {
+			service, _ := lo.Find(ctx.services, func(service config.ServiceConfig) bool {
+				return strings.ToLower(service.Name) == item.Service
+			})
+			item.Version = service.Version
+		}
+
+		return item
+	}), nil
+}
+
+func (ctx *Reconciler) plan(desired []models.DesiredApplication, deployed []models.Application) []models.PlanStep {
+	actual := lo.KeyBy(deployed, func(item models.Application) string {
+		return item.Instance
+	})
+	wanted := lo.KeyBy(desired, func(item models.DesiredApplication) string {
+		return item.Instance
+	})
+
+	sort.SliceStable(desired, func(i, j int) bool {
+		return ctx.depth(desired[i].Service, nil) < ctx.depth(desired[j].Service, nil)
+	})
+
+	steps := make([]models.PlanStep, 0)
+	for _, item := range desired {
+		current, ok := actual[item.Instance]
+
+		switch {
+		case !ok:
+			steps = append(steps, models.PlanStep{
+				Action:    models.PlanActionCreate,
+				Service:   item.Service,
+				Instance:  item.Instance,
+				Namespace: item.Namespace,
+				Cluster:   item.Cluster,
+				ToVersion: item.Version,
+			})
+		case item.Version != "" && item.Version != current.Version:
+			steps = append(steps, models.PlanStep{
+				Action:      models.PlanActionUpgrade,
+				Service:     current.Service,
+				Instance:    current.Instance,
+				Namespace:   current.Namespace,
+				Cluster:     current.Cluster,
+				FromVersion: current.Version,
+				ToVersion:   item.Version,
+			})
+		}
+	}
+
+	if !ctx.config.Prune {
+		return steps
+	}
+
+	orphans := lo.Filter(deployed, func(item models.Application, _ int) bool {
+		_, ok := wanted[item.Instance]
+
+		return !ok
+	})
+	sort.SliceStable(orphans, func(i, j int) bool {
+		return ctx.depth(orphans[i].Service, nil) > ctx.depth(orphans[j].Service, nil)
+	})
+
+	for _, item := range orphans {
+		steps = append(steps, models.PlanStep{
+			Action:      models.PlanActionPrune,
+			Service:     item.Service,
+			Instance:    item.Instance,
+			Namespace:   item.Namespace,
+			Cluster:     item.Cluster,
+			FromVersion: item.Version,
+		})
+	}
+
+	return steps
+}
+
+func (ctx *Reconciler) apply(background context.Context, plan *models.Plan) {
+	desired, _ := ctx.desired()
+	values := lo.SliceToMap(desired, func(item models.DesiredApplication) (string, map[string]string) {
+		return item.Instance, item.Values
+	})
+
+	for idx := range plan.Steps {
+		step := &plan.Steps[idx]
+
+		request := models.DeploymentRequest{
+			Service:   step.Service,
+			Instance:  step.Instance,
+			Version:   step.ToVersion,
+			Namespace: step.Namespace,
+			Cluster:   step.Cluster,
+			Requester: models.Identity{User: reconcilerRequester, System: models.SystemReconciler},
+			Values:    values[step.Instance],
+		}
+
+		var err error
+		switch step.Action {
+		case models.PlanActionCreate:
+			_, err = ctx.manager.Create(background, request)
+		case models.PlanActionUpgrade:
+			_, err = ctx.manager.Upgrade(background, request)
+		case models.PlanActionPrune:
+			err = ctx.manager.Delete(background, request)
+		}
+
+		if err != nil {
+			logger.Warn(
+				"failed to apply reconcile step",
+				zap.String("action", step.Action),
+				zap.String("instance", step.Instance),
+				zap.Error(err),
+			)
+
+			step.Error = err.Error()
+		}
+	}
+
+	plan.Applied = true
+	plan.Time = time.Now()
+}
+
+func (ctx *Reconciler) depth(service string, visited []string) int {
+	if lo.Contains(visited, service) {
+		return 0
+	}
+
+	serviceConfig, ok := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
+		return strings.ToLower(item.Name) == service
+	})
+	if !ok {
+		return 0
+	}
+
+	return lo.Max(lo.Map(serviceConfig.Depends, func(item config.ServiceDependConfig, _ int) int {
+		return ctx.depth(strings.ToLower(item.Name), append(visited, service)) + 1
+	}))
+}
+
+func (ctx *Reconciler) publish(plan *models.Plan) {
+	logger.Info("reconcile plan", zap.Bool("applied", plan.Applied), zap.Any("steps", plan.Steps))
+
+	ctx.events <- &models.SystemMessage{
+		Key: models.ReconcilePlan,
+		Value: models.Plan{
+			Applied: plan.Applied,
+			Steps:   slices.Clone(plan.Steps),
+			Time:    plan.Time,
+		},
+	}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/logger"
+	"time"
+)
+
+const schedulerInterval = 10 * time.Second
+
+var schedulableActions = []string{"create", "upgrade", "rollback", "delete", "sync"}
+
+type Scheduler struct {
+	storage    ports.Storage
+	argocd     ports.Argocd
+	authorizer usecases.Authorizer
+	audit      usecases.Audit
+	events     chan<- any
+	changes    sync.Mutex
+	mutex      sync.Mutex
+	done       chan struct{}
+	group      sync.WaitGroup
+}
+
+func NewScheduler(
+	events chan any,
+	storage ports.Storage,
+	argocd ports.Argocd,
+	authorizer usecases.Authorizer,
+	audit usecases.Audit,
+) usecases.Scheduler {
+	return &Scheduler{
+		storage:    storage,
+		argocd:     argocd,
+		authorizer: authorizer,
+		audit:      audit,
+		events:     events,
+	}
+}
+
+func (ctx *Scheduler) Schedule(background context.Context, command models.KafkaMessage) (*models.Schedule, error) {
+	action := strings.ToLower(command.Action)
+	if !lo.Contains(schedulableActions, action) {
+		return nil, models.NewError(models.ErrInvalidValues, "action '%s' cannot be scheduled", command.Action)
+	}
+	if command.Cron != "" && !command.ExecuteAt.IsZero() {
+		return nil, models.NewError(models.ErrInvalidValues, "a command is scheduled by either execute_at or cron, not both")
+	}
+
+	now := time.Now()
+	schedule := &models.Schedule{
+		ID:        uuid.NewString(),
+		State:     models.ScheduleStateActive,
+		ExecuteAt: command.ExecuteAt,
+		Cron:      command.Cron,
+		Timezone:  command.Timezone,
+		NextRun:   command.ExecuteAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	command.Action = action
+	command.Schedule = ""
+	command.ExecuteAt = time.Time{}
+	command.Cron = ""
+	command.Timezone = ""
+	command.Context = nil
+	schedule.Command = command
+
+	if schedule.Recurring() {
+		next, err := nextRun(schedule, now)
+		if err != nil {
+			return nil, models.NewError(models.ErrInvalidValues, "invalid cron expression '%s': %s", schedule.Cron, err.Error())
+		}
+		schedule.NextRun = next
+	}
+
+	request, err := ctx.target(background, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ctx.authorizer.Authorize(action, request); err != nil {
+		ctx.audit.Record("schedule", request, err)
+
+		return nil, err
+	}
+
+	if err = ctx.storage.SaveSchedule(*schedule); err != nil {
+		logger.Error("failed to save schedule", zap.String("schedule", schedule.ID), zap.Error(err))
+
+		return nil, err
+	}
+	ctx.audit.Record("schedule", request, nil)
+
+	logger.Info(
+		"command scheduled",
+		zap.String("schedule", schedule.ID),
+		zap.String("action", action),
+		zap.String("instance", command.Instance),
+		zap.String("cron", schedule.Cron),
+		zap.Time("next_run", schedule.NextRun),
+	)
+
+	ctx.publish(schedule, models.StatusEventScheduled, fmt.Sprintf(
+		"%s scheduled for %s",
+		action,
+		schedule.NextRun.Format(time.RFC3339),
+	))
+
+	return schedule, nil
+}
+
+// target resolves the application the command will act on the way the
+// deployment manager does, so the rules scoped to a service or namespace see
+// the same request now as when the command runs.
+func (ctx *Scheduler) target(background context.Context, schedule *models.Schedule) (models.DeploymentRequest, error) {
+	request := schedule.Request()
+
+	if schedule.Command.Action == "create" {
+		schedule.Command.Namespace = lo.CoalesceOrEmpty(schedule.Command.Namespace, schedule.Command.Service)
+		schedule.Command.Cluster = lo.CoalesceOrEmpty(schedule.Command.Cluster, models.DefaultCluster)
+		schedule.Command.Instance = strings.ToLower(lo.CoalesceOrEmpty(
+			schedule.Command.Instance,
+			models.InstanceName(schedule.Command.Service, schedule.Command.Namespace),
+		))
+
+		return schedule.Request(), nil
+	}
+
+	if request.Instance == "" {
+		return request, models.NewError(models.ErrInvalidValues, "%s requires an instance", schedule.Command.Action)
+	}
+
+	current, err := ctx.argocd.Get(background, request.Instance)
+	if err != nil {
+		return request, err
+	}
+
+	if request.Service != "" && request.Service != current.Service {
+		return request, models.NewError(
+			models.ErrServiceMismatch,
+			"application '%s' deploys service '%s', not '%s'",
+			request.Instance,
+			current.Service,
+			request.Service,
+		)
+	}
+
+	request.Service = current.Service
+	request.Namespace = current.Namespace
+	request.Cluster = current.Cluster
+	request.Version = lo.CoalesceOrEmpty(request.Version, current.Version)
+
+	return request, nil
+}
+
+func (ctx *Scheduler) Cancel(id string, requester models.Identity) (*models.Schedule, error) {
+	ctx.changes.Lock()
+	defer ctx.changes.Unlock()
+
+	schedule, err := ctx.storage.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.State != models.ScheduleStateActive {
+		return nil, models.NewError(models.ErrScheduleClosed, "schedule '%s' is %s", id, schedule.State)
+	}
+
+	request := schedule.Request()
+	request.Requester = requester
+	if requester.User == "" || requester.User != schedule.Command.Requester.User {
+		if err = ctx.authorizer.Authorize("cancel", request); err != nil {
+			ctx.audit.Record("cancel", request, err)
+
+			return nil, err
+		}
+	}
+
+	schedule.State = models.ScheduleStateCancelled
+	schedule.Message = "cancelled by " + principal(requester)
+	schedule.UpdatedAt = time.Now()
+
+	if err = ctx.storage.SaveSchedule(*schedule); err != nil {
+		logger.Error("failed to save schedule", zap.String("schedule", schedule.ID), zap.Error(err))
+
+		return nil, err
+	}
+	ctx.audit.Record("cancel", request, nil)
+
+	logger.Info("schedule cancelled", zap.String("schedule", schedule.ID), zap.String("user", requester.User))
+
+	ctx.publish(schedule, models.StatusEventCancelled, schedule.Message)
+
+	return schedule, nil
+}
+
+func (ctx *Scheduler) List(state string) ([]models.Schedule, error) {
+	schedules, err := ctx.storage.ListSchedules()
+	if err != nil {
+		return nil, err
+	}
+
+	schedules = lo.Filter(schedules, func(item models.Schedule, _ int) bool {
+		return state == "" || item.State == state
+	})
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRun.Before(schedules[j].NextRun)
+	})
+
+	return schedules, nil
+}
+
+func (ctx *Scheduler) Start() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done != nil {
+		return
+	}
+	ctx.done = make(chan struct{})
+
+	logger.Info("starting scheduler")
+
+	ctx.group.Add(1)
+	go ctx.run(ctx.done)
+}
+
+func (ctx *Scheduler) Stop() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.done == nil {
+		return
+	}
+	close(ctx.done)
+	ctx.done = nil
+
+	ctx.group.Wait()
+
+	logger.Info("stopped scheduler")
+}
+
+func (ctx *Scheduler) run(done <-chan struct{}) {
+	defer ctx.group.Done()
+
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx.trigger()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ctx *Scheduler) trigger() {
+	now := time.Now()
+	for _, schedule := range ctx.due(now) {
+		ctx.execute(schedule.ID, now)
+	}
+}
+
+func (ctx *Scheduler) due(now time.Time) []models.Schedule {
+	schedules, err := ctx.storage.ListSchedules()
+	if err != nil {
+		logger.Error("failed to list schedules", zap.Error(err))
+
+		return nil
+	}
+
+	return lo.Filter(schedules, func(schedule models.Schedule, _ int) bool {
+		return schedule.State == models.ScheduleStateActive && !schedule.NextRun.After(now)
+	})
+}
+
+// execute hands the command to the processor before it records the run, so a
+// restart in between runs the command again instead of losing it.
+func (ctx *Scheduler) execute(id string, now time.Time) {
+	ctx.changes.Lock()
+	defer ctx.changes.Unlock()
+
+	schedule, err := ctx.storage.GetSchedule(id)
+	if err != nil {
+		logger.Error("failed to get schedule", zap.String("schedule", id), zap.Error(err))
+
+		return
+	}
+	if schedule.State != models.ScheduleStateActive || schedule.NextRun.After(now) {
+		return
+	}
+
+	schedule.LastRun = now
+	schedule.UpdatedAt = now
+	schedule.Runs++
+	schedule.State = models.ScheduleStateCompleted
+
+	if schedule.Recurring() {
+		next, err := nextRun(schedule, now)
+		if err != nil {
+			schedule.Message = err.Error()
+		} else {
+			schedule.State = models.ScheduleStateActive
+			schedule.NextRun = next
+		}
+	}
+
+	logger.Info(
+		"running scheduled command",
+		zap.String("schedule", schedule.ID),
+		zap.String("action", schedule.Command.Action),
+		zap.String("instance", schedule.Command.Instance),
+		zap.Int("run", schedule.Runs),
+	)
+
+	ctx.publish(schedule, models.StatusEventTriggered, fmt.Sprintf(
+		"scheduled %s started (run %d)",
+		schedule.Command.Action,
+		schedule.Runs,
+	))
+	ctx.events <- schedule.Execution()
+
+	if err = ctx.storage.SaveSchedule(*schedule); err != nil {
+		logger.Error("failed to save schedule", zap.String("schedule", schedule.ID), zap.Error(err))
+	}
+}
+
+func (ctx *Scheduler) publish(schedule *models.Schedule, eventType, message string) {
+	ctx.events <- &models.SystemMessage{
+		Key:   models.ArgocdApplicationStatus,
+		Value: models.NewStatusEvent(eventType, schedule.Request(), message),
+	}
+}
+
+func nextRun(schedule *models.Schedule, after time.Time) (time.Time, error) {
+	expression, err := parseCron(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := expression.Next(after)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression '%s' never runs", schedule.Cron)
+	}
+
+	return next, nil
+}
+
+func parseCron(expression, timezone string) (cron.Schedule, error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+		expression = "CRON_TZ=" + timezone + " " + expression
+	}
+
+	return cron.ParseStandard(expression)
+}
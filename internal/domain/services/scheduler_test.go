@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cron     string
+		timezone string
+		expected time.Time
+		invalid  bool
+	}{
+		{name: "daily", cron: "30 2 * * *", expected: time.Date(2026, 3, 29, 2, 30, 0, 0, time.UTC)},
+		{name: "timezone", cron: "0 9 * * *", timezone: "America/New_York", expected: time.Date(2026, 3, 28, 13, 0, 0, 0, time.UTC)},
+		{name: "hour skipped by daylight saving", cron: "30 2 * * *", timezone: "Europe/Berlin", expected: time.Date(2026, 3, 30, 0, 30, 0, 0, time.UTC)},
+		{name: "invalid expression", cron: "every day", invalid: true},
+		{name: "unknown timezone", cron: "0 9 * * *", timezone: "Mars/Olympus", invalid: true},
+		{name: "never runs", cron: "0 0 30 2 *", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next, err := nextRun(&models.Schedule{Cron: test.cron, Timezone: test.timezone}, after)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", next)
+				}
+
+				return
+			}
+
+			if err != nil || !next.Equal(test.expected) {
+				t.Fatalf("expected %s, got %s (%v)", test.expected, next, err)
+			}
+		})
+	}
+}
+
+func newTestScheduler(rules []config.AuthorizationRuleConfig) (*Scheduler, *memoryStorage, chan any) {
+	conf := &config.Config{Authorization: config.AuthorizationConfig{Enabled: rules != nil, Rules: rules}}
+	storage := newMemoryStorage()
+	events := make(chan any, 64)
+	argocd := &memoryArgocd{applications: []models.Application{
+		{Instance: "api", Service: "api", Namespace: "api", Cluster: models.DefaultCluster, Version: "1.0.0"},
+		{Instance: "billing", Service: "billing", Namespace: "billing", Cluster: models.DefaultCluster, Version: "1.0.0"},
+	}}
+
+	return NewScheduler(events, storage, argocd, NewAuthorizer(conf), NewAudit(conf, &memoryAuditLog{})).(*Scheduler), storage, events
+}
+
+func TestSchedulerSchedule(t *testing.T) {
+	rules := []config.AuthorizationRuleConfig{
+		{Teams: []string{"payments"}, Actions: []string{"upgrade", "create"}, Services: []string{"api"}, Namespaces: []string{"api"}},
+	}
+
+	tests := []struct {
+		name    string
+		command models.KafkaMessage
+		denied  bool
+		invalid bool
+	}{
+		{
+			name:    "allowed upgrade",
+			command: models.KafkaMessage{Action: "Upgrade", Instance: "api", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+		},
+		{
+			name:    "create in the default namespace",
+			command: models.KafkaMessage{Action: "create", Service: "api", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+		},
+		{
+			name:    "action outside the rules",
+			command: models.KafkaMessage{Action: "delete", Instance: "api", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+			denied:  true,
+		},
+		{
+			name:    "service outside the rules",
+			command: models.KafkaMessage{Action: "upgrade", Instance: "billing", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+			denied:  true,
+		},
+		{
+			name:    "namespace outside the rules",
+			command: models.KafkaMessage{Action: "create", Service: "api", Namespace: "staging", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+			denied:  true,
+		},
+		{
+			name:    "unschedulable action",
+			command: models.KafkaMessage{Action: "approve", Cron: "0 9 * * *", Requester: models.Identity{Team: "payments"}},
+			invalid: true,
+		},
+		{
+			name:    "cron and execute_at",
+			command: models.KafkaMessage{Action: "upgrade", Instance: "api", Cron: "0 9 * * *", ExecuteAt: time.Now(), Requester: models.Identity{Team: "payments"}},
+			invalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, storage, _ := newTestScheduler(rules)
+
+			schedule, err := scheduler.Schedule(context.Background(), test.command)
+			saved, _ := storage.ListSchedules()
+
+			switch {
+			case test.denied:
+				if !errors.Is(err, models.ErrPermissionDenied) || len(saved) != 0 {
+					t.Fatalf("expected the schedule to be denied and not saved, got %v and %d schedule(s)", err, len(saved))
+				}
+			case test.invalid:
+				if !errors.Is(err, models.ErrInvalidValues) || len(saved) != 0 {
+					t.Fatalf("expected the schedule to be invalid and not saved, got %v and %d schedule(s)", err, len(saved))
+				}
+			case err != nil || len(saved) != 1 || schedule.NextRun.IsZero():
+				t.Fatalf("expected one saved schedule, got %+v and %d schedule(s) (%v)", schedule, len(saved), err)
+			}
+		})
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		schedule models.Schedule
+		runs     bool
+		state    string
+	}{
+		{
+			name:     "due one-off schedule",
+			schedule: models.Schedule{State: models.ScheduleStateActive, ExecuteAt: now.Add(-time.Second), NextRun: now.Add(-time.Second)},
+			runs:     true,
+			state:    models.ScheduleStateCompleted,
+		},
+		{
+			name:     "due recurring schedule",
+			schedule: models.Schedule{State: models.ScheduleStateActive, Cron: "0 9 * * *", NextRun: now.Add(-time.Second)},
+			runs:     true,
+			state:    models.ScheduleStateActive,
+		},
+		{
+			name:     "future schedule",
+			schedule: models.Schedule{State: models.ScheduleStateActive, NextRun: now.Add(time.Hour)},
+			state:    models.ScheduleStateActive,
+		},
+		{
+			name:     "cancelled schedule",
+			schedule: models.Schedule{State: models.ScheduleStateCancelled, NextRun: now.Add(-time.Second)},
+			state:    models.ScheduleStateCancelled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, storage, events := newTestScheduler(nil)
+
+			test.schedule.ID = "schedule"
+			test.schedule.Command = models.KafkaMessage{Action: "upgrade", Instance: "api", Version: "1.1.0"}
+			if err := storage.SaveSchedule(test.schedule); err != nil {
+				t.Fatal(err)
+			}
+
+			scheduler.trigger()
+			close(events)
+
+			var executions []*models.KafkaMessage
+			for event := range events {
+				if message, ok := event.(*models.KafkaMessage); ok {
+					executions = append(executions, message)
+				}
+			}
+
+			schedule, err := storage.GetSchedule("schedule")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schedule.State != test.state {
+				t.Fatalf("expected state %s, got %s", test.state, schedule.State)
+			}
+
+			if !test.runs {
+				if len(executions) != 0 || schedule.Runs != 0 {
+					t.Fatalf("expected no run, got %d execution(s) and %d run(s)", len(executions), schedule.Runs)
+				}
+
+				return
+			}
+
+			if len(executions) != 1 || executions[0].Schedule != "schedule" || !executions[0].Internal || schedule.Runs != 1 {
+				t.Fatalf("expected one internal execution of the schedule, got %+v and %d run(s)", executions, schedule.Runs)
+			}
+			if test.schedule.Recurring() && !schedule.NextRun.After(now) {
+				t.Fatalf("expected the next run after %s, got %s", now, schedule.NextRun)
+			}
+		})
+	}
+}
+
+func TestSchedulerTriggerOnce(t *testing.T) {
+	scheduler, storage, events := newTestScheduler(nil)
+
+	schedule := models.Schedule{
+		ID:        "schedule",
+		State:     models.ScheduleStateActive,
+		ExecuteAt: time.Now().Add(-time.Second),
+		NextRun:   time.Now().Add(-time.Second),
+		Command:   models.KafkaMessage{Action: "upgrade", Instance: "api", Version: "1.1.0"},
+	}
+	if err := storage.SaveSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	var group sync.WaitGroup
+	for range 8 {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			scheduler.trigger()
+		}()
+	}
+	group.Wait()
+	close(events)
+
+	executions := 0
+	for event := range events {
+		if _, ok := event.(*models.KafkaMessage); ok {
+			executions++
+		}
+	}
+
+	saved, err := storage.GetSchedule("schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if executions != 1 || saved.Runs != 1 || saved.State != models.ScheduleStateCompleted {
+		t.Fatalf("expected exactly one execution, got %d execution(s), %d run(s) and state %s", executions, saved.Runs, saved.State)
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"time"
+)
+
+type memoryStorage struct {
+	mutex     sync.Mutex
+	approvals map[string]models.Approval
+	deferrals map[string]models.Deferral
+	schedules map[string]models.Schedule
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		approvals: map[string]models.Approval{},
+		deferrals: map[string]models.Deferral{},
+		schedules: map[string]models.Schedule{},
+	}
+}
+
+func (ctx *memoryStorage) AppendHistory(models.HistoryRecord) error {
+	return nil
+}
+
+func (ctx *memoryStorage) QueryHistory(models.HistoryQuery) ([]models.HistoryRecord, error) {
+	return nil, nil
+}
+
+func (ctx *memoryStorage) ClaimNonce(string, time.Time) (bool, error) {
+	return true, nil
+}
+
+func (ctx *memoryStorage) Prune(time.Time) error {
+	return nil
+}
+
+func (ctx *memoryStorage) SaveJob(models.Job) error {
+	return nil
+}
+
+func (ctx *memoryStorage) GetJob(string) (*models.Job, error) {
+	return nil, models.ErrJobNotFound
+}
+
+func (ctx *memoryStorage) ListActiveJobs() ([]models.Job, error) {
+	return nil, nil
+}
+
+func (ctx *memoryStorage) SaveApproval(approval models.Approval) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.approvals[approval.ID] = approval
+
+	return nil
+}
+
+func (ctx *memoryStorage) GetApproval(id string) (*models.Approval, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	approval, ok := ctx.approvals[id]
+	if !ok {
+		return nil, models.ErrApprovalNotFound
+	}
+
+	return &approval, nil
+}
+
+func (ctx *memoryStorage) ListApprovals() ([]models.Approval, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	approvals := make([]models.Approval, 0, len(ctx.approvals))
+	for _, approval := range ctx.approvals {
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, nil
+}
+
+func (ctx *memoryStorage) SaveDeferral(deferral models.Deferral) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.deferrals[deferral.ID] = deferral
+
+	return nil
+}
+
+func (ctx *memoryStorage) ListDeferrals() ([]models.Deferral, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	deferrals := make([]models.Deferral, 0, len(ctx.deferrals))
+	for _, deferral := range ctx.deferrals {
+		deferrals = append(deferrals, deferral)
+	}
+
+	return deferrals, nil
+}
+
+func (ctx *memoryStorage) DeleteDeferral(id string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	delete(ctx.deferrals, id)
+
+	return nil
+}
+
+func (ctx *memoryStorage) SaveSchedule(schedule models.Schedule) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.schedules[schedule.ID] = schedule
+
+	return nil
+}
+
+func (ctx *memoryStorage) GetSchedule(id string) (*models.Schedule, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	schedule, ok := ctx.schedules[id]
+	if !ok {
+		return nil, models.ErrScheduleNotFound
+	}
+
+	return &schedule, nil
+}
+
+func (ctx *memoryStorage) ListSchedules() ([]models.Schedule, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	schedules := make([]models.Schedule, 0, len(ctx.schedules))
+	for _, schedule := range ctx.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+func (ctx *memoryStorage) Close() error {
+	return nil
+}
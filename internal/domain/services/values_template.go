@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samber/lo"
+	"strings"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"text/template"
+)
+
+type valuesTemplateContext struct {
+	Service     string
+	Instance    string
+	Version     string
+	Namespace   string
+	Requester   string
+	Environment string
+	Deps        map[string]models.Application
+}
+
+func (ctx *DeploymentManager) renderValues(request models.DeploymentRequest, deployed []models.Application) (map[string]string, error) {
+	service, _ := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
+		return strings.ToLower(item.Name) == request.Service
+	})
+
+	missingKey := "missingkey=zero"
+	if service.Defaults.Strict {
+		missingKey = "missingkey=error"
+	}
+
+	data := valuesTemplateContext{
+		Service:     request.Service,
+		Instance:    request.Instance,
+		Version:     request.Version,
+		Namespace:   request.Namespace,
+		Requester:   request.Requester.User,
+		Environment: ctx.environment,
+		Deps:        make(map[string]models.Application),
+	}
+	for _, item := range deployed {
+		if _, ok := data.Deps[item.Service]; !ok || item.Namespace == request.Namespace {
+			data.Deps[item.Service] = item
+		}
+	}
+
+	values := make(map[string]string, len(service.Defaults.Values)+len(request.Values))
+	for key, value := range service.Defaults.Values {
+		tmpl, err := template.New(key).Option(missingKey).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default value template '%s': %w", key, err)
+		}
+
+		var buffer bytes.Buffer
+		if err = tmpl.Execute(&buffer, data); err != nil {
+			return nil, fmt.Errorf("failed to render default value '%s': %w", key, err)
+		}
+
+		values[key] = buffer.String()
+	}
+
+	for key, value := range request.Values {
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+func (ctx *DeploymentManager) carriedValues(current models.Application, deployed []models.Application) map[string]string {
+	defaults, err := ctx.renderValues(models.DeploymentRequest{
+		Service:   current.Service,
+		Instance:  current.Instance,
+		Version:   current.Version,
+		Namespace: current.Namespace,
+		Cluster:   current.Cluster,
+	}, deployed)
+	if err != nil {
+		defaults = map[string]string{}
+	}
+
+	return lo.OmitBy(current.Values, func(key string, value string) bool {
+		rendered, ok := defaults[key]
+
+		return ok && rendered == value
+	})
+}
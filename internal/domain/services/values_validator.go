@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/zap"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/internal/ports"
+	"tera/deployment/internal/usecases"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+)
+
+type ValuesValidator struct {
+	repository ports.ChartRepository
+	services   []config.ServiceConfig
+	schemas    sync.Map
+}
+
+func NewValuesValidator(conf *config.Config, repository ports.ChartRepository) usecases.ValuesValidator {
+	return &ValuesValidator{
+		repository: repository,
+		services:   conf.Services,
+	}
+}
+
+func (ctx *ValuesValidator) Validate(service, version string, values map[string]string) error {
+	schema, err := ctx.loadSchema(service, version)
+	if err != nil {
+		logger.Error("failed to load values schema", zap.String("service", service), zap.Error(err))
+
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(expandValues(values)))
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	return &models.ValidationError{
+		Service: service,
+		Violations: lo.Map(result.Errors(), func(item gojsonschema.ResultError, _ int) models.Violation {
+			return models.Violation{
+				Path:    violationPath(item),
+				Message: item.Description(),
+			}
+		}),
+	}
+}
+
+func (ctx *ValuesValidator) loadSchema(service, version string) (*gojsonschema.Schema, error) {
+	serviceConfig, ok := lo.Find(ctx.services, func(item config.ServiceConfig) bool {
+		return strings.ToLower(item.Name) == service
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	// A chart schema is cached per version, except when no version is configured
+	// either, as the repository then serves its latest chart.
+	version = lo.CoalesceOrEmpty(version, serviceConfig.Version)
+	key, cacheable := service, !serviceConfig.Schema.Chart || version != ""
+	if serviceConfig.Schema.Chart {
+		key = fmt.Sprintf("%s@%s", service, version)
+	}
+	if cached, ok := ctx.schemas.Load(key); ok {
+		return cached.(*gojsonschema.Schema), nil
+	}
+
+	var loader gojsonschema.JSONLoader
+	switch {
+	case serviceConfig.Schema.Inline != nil:
+		loader = gojsonschema.NewGoLoader(serviceConfig.Schema.Inline)
+	case serviceConfig.Schema.File != "":
+		data, err := os.ReadFile(serviceConfig.Schema.File)
+		if err != nil {
+			return nil, err
+		}
+
+		loader = gojsonschema.NewBytesLoader(data)
+	case serviceConfig.Schema.Chart:
+		data, err := ctx.repository.GetValuesSchema(service, version)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			if cacheable {
+				ctx.schemas.Store(key, (*gojsonschema.Schema)(nil))
+			}
+
+			return nil, nil
+		}
+
+		loader = gojsonschema.NewBytesLoader(data)
+	default:
+		return nil, nil
+	}
+
+	schema, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		ctx.schemas.Store(key, schema)
+	}
+
+	return schema, nil
+}
+
+func expandValues(values map[string]string) map[string]any {
+	result := make(map[string]any)
+
+	for key, value := range values {
+		parts := strings.Split(key, ".")
+
+		node := result
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[part] = child
+			}
+
+			node = child
+		}
+
+		node[parts[len(parts)-1]] = parseValue(value)
+	}
+
+	return result
+}
+
+// parseValue types a value the way helm --set does, which is how Argo CD passes
+// the parameters: only booleans, null and integers without a leading zero are
+// typed, everything else stays a string.
+func parseValue(value string) any {
+	switch {
+	case strings.EqualFold(value, "true"):
+		return true
+	case strings.EqualFold(value, "false"):
+		return false
+	case strings.EqualFold(value, "null"):
+		return nil
+	case value == "0":
+		return int64(0)
+	}
+
+	if value != "" && value[0] != '0' {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+func violationPath(item gojsonschema.ResultError) string {
+	path := "$"
+	if field := item.Field(); field != gojsonschema.STRING_CONTEXT_ROOT {
+		path = "$." + field
+	}
+
+	if property, ok := item.Details()["property"].(string); ok && item.Type() == "required" {
+		path = path + "." + property
+	}
+
+	return path
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"tera/deployment/internal/domain/models"
+	"tera/deployment/pkg/config"
+	"testing"
+)
+
+type memoryChartRepository struct {
+	schemas map[string][]byte
+	calls   []string
+}
+
+func (ctx *memoryChartRepository) GetValuesSchema(chart, version string) ([]byte, error) {
+	ctx.calls = append(ctx.calls, chart+"@"+version)
+
+	return ctx.schemas[chart+"@"+version], nil
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected any
+	}{
+		{value: "true", expected: true},
+		{value: "False", expected: false},
+		{value: "null", expected: nil},
+		{value: "0", expected: int64(0)},
+		{value: "42", expected: int64(42)},
+		{value: "-3", expected: int64(-3)},
+		{value: "007", expected: "007"},
+		{value: "0x1f", expected: "0x1f"},
+		{value: "1.5", expected: "1.5"},
+		{value: "", expected: ""},
+		{value: "api", expected: "api"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if parsed := parseValue(test.value); !reflect.DeepEqual(parsed, test.expected) {
+				t.Fatalf("expected %#v, got %#v", test.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestValuesValidatorChartSchema(t *testing.T) {
+	repository := &memoryChartRepository{
+		schemas: map[string][]byte{
+			"api@1.0.0": []byte(`{"properties": {"replicas": {"type": "integer"}, "tag": {"type": "string"}}}`),
+		},
+	}
+	validator := NewValuesValidator(&config.Config{
+		Services: []config.ServiceConfig{
+			{Name: "api", Version: "1.0.0", Schema: config.ServiceSchemaConfig{Chart: true}},
+			{Name: "billing", Schema: config.ServiceSchemaConfig{Chart: true}},
+		},
+	}, repository)
+
+	var validationErr *models.ValidationError
+	if err := validator.Validate("api", "", map[string]string{"replicas": "two"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected the configured version's schema to reject the values, got %v", err)
+	}
+	if err := validator.Validate("api", "1.0.0", map[string]string{"replicas": "3", "tag": "007"}); err != nil {
+		t.Fatalf("expected the values to validate, got %v", err)
+	}
+	if err := validator.Validate("api", "2.0.0", map[string]string{"replicas": "two"}); err != nil {
+		t.Fatalf("expected a chart without a schema to accept any values, got %v", err)
+	}
+	if err := validator.Validate("api", "2.0.0", nil); err != nil {
+		t.Fatalf("expected a chart without a schema to accept any values, got %v", err)
+	}
+
+	for range 2 {
+		if err := validator.Validate("billing", "", nil); err != nil {
+			t.Fatalf("expected a chart without a schema to accept any values, got %v", err)
+		}
+	}
+
+	expected := []string{"api@1.0.0", "api@2.0.0", "billing@", "billing@"}
+	if !reflect.DeepEqual(repository.calls, expected) {
+		t.Fatalf("expected schema lookups %v, got %v", expected, repository.calls)
+	}
+}
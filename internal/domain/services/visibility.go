@@ -0,0 +1,74 @@
+package services
+
+import (
+	"github.com/samber/lo"
+	"tera/deployment/internal/domain/models"
+)
+
+// Visible trims a read result to the applications the requester may fetch and
+// reports whether anything is left to show. Results that belong to no
+// application, such as security events, are shown to requesters who may fetch
+// every application.
+func (ctx *Authorizer) Visible(requester models.Identity, value any) (any, bool) {
+	if !ctx.enabled {
+		return value, true
+	}
+
+	fetch := func(request models.DeploymentRequest) bool {
+		request.Requester = requester
+
+		return ctx.Authorize("fetch", request) == nil
+	}
+	target := func(service, namespace, cluster string) bool {
+		return fetch(models.DeploymentRequest{Service: service, Namespace: namespace, Cluster: cluster})
+	}
+	application := func(item models.Application, _ int) bool {
+		return target(item.Service, item.Namespace, item.Cluster)
+	}
+
+	switch value := value.(type) {
+	case *models.StatusEvent:
+		return value, target(value.Service, value.Namespace, value.Cluster)
+	case *models.Application:
+		return value, application(*value, 0)
+	case []models.Application:
+		return lo.Filter(value, application), true
+	case *models.Job:
+		return value, target(value.Service, value.Namespace, value.Cluster)
+	case []models.HistoryRecord:
+		return lo.Filter(value, func(item models.HistoryRecord, _ int) bool {
+			return target(item.Service, item.Namespace, item.Cluster)
+		}), true
+	case *models.Approval:
+		return value, fetch(value.Request)
+	case []models.Approval:
+		return lo.Filter(value, func(item models.Approval, _ int) bool { return fetch(item.Request) }), true
+	case *models.Schedule:
+		return value, fetch(value.Request())
+	case []models.Schedule:
+		return lo.Filter(value, func(item models.Schedule, _ int) bool { return fetch(item.Request()) }), true
+	case []models.Deferral:
+		return lo.Filter(value, func(item models.Deferral, _ int) bool { return fetch(item.Request) }), true
+	case models.Plan:
+		value.Steps = lo.Filter(value.Steps, func(item models.PlanStep, _ int) bool {
+			return target(item.Service, item.Namespace, item.Cluster)
+		})
+
+		return value, true
+	case *models.Plan:
+		plan, _ := ctx.Visible(requester, *value)
+		visible := plan.(models.Plan)
+
+		return &visible, true
+	case *models.DependencyGraph:
+		return &models.DependencyGraph{
+			Nodes: lo.Map(value.Nodes, func(node models.DependencyNode, _ int) models.DependencyNode {
+				node.Instances = lo.Filter(node.Instances, application)
+
+				return node
+			}),
+		}, true
+	default:
+		return value, fetch(models.DeploymentRequest{})
+	}
+}
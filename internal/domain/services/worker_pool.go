@@ -0,0 +1,107 @@
+package services
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+type workerPool struct {
+	queues  [][]func()
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	group   sync.WaitGroup
+	pending int
+	limit   int
+	paused  bool
+	closed  bool
+	pause   func()
+	resume  func()
+}
+
+func newWorkerPool(concurrency, limit int, pause, resume func()) *workerPool {
+	pool := &workerPool{
+		queues: make([][]func(), concurrency),
+		limit:  limit,
+		pause:  pause,
+		resume: resume,
+	}
+	pool.ready = sync.NewCond(&pool.mutex)
+
+	for idx := range pool.queues {
+		pool.group.Add(1)
+		go pool.work(idx)
+	}
+
+	return pool
+}
+
+// Submit never blocks: the caller also drains the events that running tasks
+// publish, so waiting here for a free slot would deadlock the processor.
+func (ctx *workerPool) Submit(key string, task func()) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.closed {
+		return false
+	}
+
+	idx := ctx.partition(key)
+	ctx.queues[idx] = append(ctx.queues[idx], task)
+
+	ctx.pending++
+	if !ctx.paused && ctx.pending >= ctx.limit {
+		ctx.paused = true
+		ctx.pause()
+	}
+
+	ctx.ready.Broadcast()
+
+	return true
+}
+
+func (ctx *workerPool) Close() {
+	ctx.mutex.Lock()
+	ctx.closed = true
+	ctx.ready.Broadcast()
+	ctx.mutex.Unlock()
+
+	ctx.group.Wait()
+}
+
+func (ctx *workerPool) work(idx int) {
+	defer ctx.group.Done()
+
+	for {
+		ctx.mutex.Lock()
+		for len(ctx.queues[idx]) == 0 && !ctx.closed {
+			ctx.ready.Wait()
+		}
+		if len(ctx.queues[idx]) == 0 {
+			ctx.mutex.Unlock()
+
+			return
+		}
+
+		task := ctx.queues[idx][0]
+		ctx.queues[idx][0] = nil
+		ctx.queues[idx] = ctx.queues[idx][1:]
+		ctx.mutex.Unlock()
+
+		task()
+
+		ctx.mutex.Lock()
+		ctx.pending--
+		if ctx.paused && ctx.pending <= ctx.limit/2 {
+			ctx.paused = false
+			ctx.resume()
+		}
+		ctx.mutex.Unlock()
+	}
+}
+
+func (ctx *workerPool) partition(key string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+
+	return int(hash.Sum32() % uint32(len(ctx.queues)))
+}
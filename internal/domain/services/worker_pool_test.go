@@ -0,0 +1,93 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolOrder(t *testing.T) {
+	pool := newWorkerPool(4, 1000, func() {}, func() {})
+
+	var mutex sync.Mutex
+	seen := map[string][]int{}
+
+	keys := []string{"api", "api-prod", "billing", "search", "gateway"}
+	for idx := range 200 {
+		key := keys[idx%len(keys)]
+		pool.Submit(key, func() {
+			mutex.Lock()
+			seen[key] = append(seen[key], idx)
+			mutex.Unlock()
+		})
+	}
+	pool.Close()
+
+	for _, key := range keys {
+		order := seen[key]
+		if len(order) != 200/len(keys) {
+			t.Fatalf("expected %d tasks for %s, got %d", 200/len(keys), key, len(order))
+		}
+
+		for idx := 1; idx < len(order); idx++ {
+			if order[idx] < order[idx-1] {
+				t.Fatalf("expected the tasks of %s in submission order, got %v", key, order)
+			}
+		}
+	}
+}
+
+func TestWorkerPoolThrottle(t *testing.T) {
+	var paused, resumed atomic.Int32
+	pool := newWorkerPool(1, 4, func() { paused.Add(1) }, func() { resumed.Add(1) })
+	defer pool.Close()
+
+	release := make(chan struct{})
+	done := make(chan struct{}, 8)
+	submit := func() {
+		pool.Submit("api", func() {
+			<-release
+			done <- struct{}{}
+		})
+	}
+
+	for range 3 {
+		submit()
+	}
+	if paused.Load() != 0 {
+		t.Fatalf("expected no pause below the limit, got %d", paused.Load())
+	}
+
+	submit()
+	submit()
+	if paused.Load() != 1 {
+		t.Fatalf("expected one pause at the limit, got %d", paused.Load())
+	}
+
+	// 5 pending: the consumer resumes once at most half the limit is left.
+	for count := 1; count <= 5; count++ {
+		release <- struct{}{}
+		<-done
+
+		expected := int32(0)
+		if 5-count <= 2 {
+			expected = 1
+		}
+
+		if !waitFor(func() bool { return resumed.Load() == expected }) {
+			t.Fatalf("expected %d resume(s) with %d task(s) pending, got %d", expected, 5-count, resumed.Load())
+		}
+	}
+}
+
+func waitFor(condition func() bool) bool {
+	for range 100 {
+		if condition() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return condition()
+}
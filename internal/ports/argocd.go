@@ -1,9 +1,28 @@
 package ports
 
-import "tera/deployment/internal/domain/models"
+import (
+	"context"
+	"tera/deployment/internal/domain/models"
+)
 
 type Argocd interface {
-	GetList() ([]models.Application, error)
+	GetList(background context.Context) ([]models.Application, error)
 
-	Create(service, version, namespace string, values map[string]string) (*models.Application, error)
+	Get(background context.Context, instance string) (*models.Application, error)
+
+	Create(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+
+	Update(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+
+	Delete(background context.Context, instance string) error
+
+	History(background context.Context, instance string) ([]models.Revision, error)
+
+	Adopt(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+
+	Sync(background context.Context, instance string) error
+
+	SyncWindows(background context.Context, windows []models.SyncWindow) error
+
+	Ping(background context.Context) error
 }
@@ -0,0 +1,9 @@
+package ports
+
+import "tera/deployment/internal/domain/models"
+
+type AuditLog interface {
+	Append(entry models.AuditEntry) error
+	Entries() ([]models.AuditEntry, error)
+	Close() error
+}
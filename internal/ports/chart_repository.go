@@ -0,0 +1,5 @@
+package ports
+
+type ChartRepository interface {
+	GetValuesSchema(chart, version string) ([]byte, error)
+}
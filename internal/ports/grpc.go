@@ -0,0 +1,6 @@
+package ports
+
+type GRPCServer interface {
+	Start() error
+	Close() error
+}
@@ -0,0 +1,6 @@
+package ports
+
+type HTTPServer interface {
+	Start() error
+	Close() error
+}
@@ -1,14 +1,22 @@
 package ports
 
 import (
+	"context"
 	"tera/deployment/internal/domain/models"
 )
 
 type KafkaConsumer interface {
 	Start(events chan<- any) error
+	Subscribe() error
+	Unsubscribe() error
+	Pause() error
+	Resume() error
+	Alive() models.HealthCheck
+	Health() models.HealthCheck
 	Close() error
 }
 
 type KafkaProducer interface {
-	Produce(key models.Key, value any) error
+	Produce(background context.Context, key models.Key, value any) error
+	Health() models.HealthCheck
 }
@@ -0,0 +1,7 @@
+package ports
+
+type LeaderElector interface {
+	Run(started func(), stopped func()) error
+	Leader() string
+	Close() error
+}
@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"tera/deployment/internal/domain/models"
+	"time"
+)
+
+type Storage interface {
+	AppendHistory(record models.HistoryRecord) error
+	QueryHistory(query models.HistoryQuery) ([]models.HistoryRecord, error)
+
+	SaveJob(job models.Job) error
+	GetJob(id string) (*models.Job, error)
+	ListActiveJobs() ([]models.Job, error)
+
+	SaveApproval(approval models.Approval) error
+	GetApproval(id string) (*models.Approval, error)
+	ListApprovals() ([]models.Approval, error)
+
+	SaveDeferral(deferral models.Deferral) error
+	ListDeferrals() ([]models.Deferral, error)
+	DeleteDeferral(id string) error
+
+	SaveSchedule(schedule models.Schedule) error
+	GetSchedule(id string) (*models.Schedule, error)
+	ListSchedules() ([]models.Schedule, error)
+
+	// ClaimNonce records a signed command's nonce until expires and reports false
+	// when it is still claimed.
+	ClaimNonce(nonce string, expires time.Time) (bool, error)
+
+	Prune(before time.Time) error
+
+	Close() error
+}
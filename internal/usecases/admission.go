@@ -0,0 +1,7 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type AdmissionController interface {
+	Admit(action string, request models.DeploymentRequest, current *models.Application, deployed []models.Application) error
+}
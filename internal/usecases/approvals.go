@@ -0,0 +1,16 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type Approvals interface {
+	LeaderDuty
+
+	Enabled() bool
+	Protected(namespace string) bool
+	Open(action string, request models.DeploymentRequest, fromVersion string) (*models.Approval, error)
+	Vote(id string, voter models.Identity, approve bool, comment string) (*models.Approval, error)
+	Admit(id string, action string, request models.DeploymentRequest) (*models.Approval, error)
+	Complete(approval *models.Approval, job string, err error)
+	Get(id string) (*models.Approval, error)
+	List(state string) ([]models.Approval, error)
+}
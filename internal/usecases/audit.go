@@ -0,0 +1,10 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type Audit interface {
+	Record(action string, request models.DeploymentRequest, err error)
+	Notice(action string, request models.DeploymentRequest, message string)
+	Verify() models.AuditVerification
+	Health() models.HealthCheck
+}
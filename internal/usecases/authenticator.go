@@ -0,0 +1,8 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type Authenticator interface {
+	Enabled() bool
+	Authenticate(token string) (models.Identity, error)
+}
@@ -0,0 +1,9 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type Authorizer interface {
+	Enabled() bool
+	Authorize(action string, request models.DeploymentRequest) error
+	Visible(requester models.Identity, value any) (any, bool)
+}
@@ -1,8 +1,21 @@
 package usecases
 
-import "tera/deployment/internal/domain/models"
+import (
+	"context"
+	"tera/deployment/internal/domain/models"
+)
 
 type DeploymentManager interface {
-	GetList() ([]models.Application, error)
-	Create(service, version, namespace string, values map[string]string) (*models.Application, error)
+	GetList(background context.Context) ([]models.Application, error)
+	Get(background context.Context, instance string) (*models.Application, error)
+	Create(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+	Upgrade(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+	Delete(background context.Context, request models.DeploymentRequest) error
+	History(background context.Context, instance string) ([]models.Revision, error)
+	Rollback(background context.Context, request models.DeploymentRequest, revision int64) (*models.Application, error)
+	Sync(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+	Graph(background context.Context) (*models.DependencyGraph, error)
+	Adopt(background context.Context, request models.DeploymentRequest) (*models.Application, error)
+	Approve(background context.Context, id string, voter models.Identity, comment string) (*models.Approval, error)
+	Reject(background context.Context, id string, voter models.Identity, comment string) (*models.Approval, error)
 }
@@ -0,0 +1,8 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type EventBroker interface {
+	Publish(message *models.SystemMessage)
+	Subscribe(filter models.EventFilter) (<-chan *models.SystemMessage, func())
+}
@@ -2,5 +2,6 @@ package usecases
 
 type EventProcessor interface {
 	Register() error
+	Intake() LeaderDuty
 	Close() error
 }
@@ -0,0 +1,13 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type Freeze interface {
+	LeaderDuty
+
+	Enabled() bool
+	Active(request models.DeploymentRequest) *models.FreezeWindow
+	Windows() []models.FreezeWindow
+	Defer(action string, request models.DeploymentRequest, revision int64, window models.FreezeWindow) (*models.Deferral, error)
+	Deferrals() ([]models.Deferral, error)
+}
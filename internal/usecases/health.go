@@ -0,0 +1,10 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type HealthChecker interface {
+	Start() error
+	Close() error
+	Liveness() models.HealthReport
+	Readiness() models.HealthReport
+}
@@ -0,0 +1,10 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type History interface {
+	RecordCommand(action string, request models.DeploymentRequest)
+	RecordOperation(action string, request models.DeploymentRequest, err error)
+	RecordEvent(event *models.StatusEvent)
+	Query(query models.HistoryQuery) ([]models.HistoryRecord, error)
+}
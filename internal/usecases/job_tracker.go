@@ -0,0 +1,13 @@
+package usecases
+
+import "tera/deployment/internal/domain/models"
+
+type JobTracker interface {
+	LeaderDuty
+
+	Begin(action string, request *models.DeploymentRequest) *models.Job
+	Run(job *models.Job)
+	Watch(job *models.Job, application models.Application)
+	Finish(job *models.Job, err error)
+	Get(id string) (*models.Job, error)
+}
@@ -0,0 +1,13 @@
+package usecases
+
+type LeaderDuty interface {
+	Start()
+	Stop()
+}
+
+type Leadership interface {
+	Start() error
+	Close() error
+	IsLeader() bool
+	Leader() string
+}
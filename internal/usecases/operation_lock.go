@@ -0,0 +1,7 @@
+package usecases
+
+import "context"
+
+type OperationLock interface {
+	Acquire(background context.Context, key string, queued func(depth int)) (func(), error)
+}
@@ -0,0 +1,13 @@
+package usecases
+
+import (
+	"context"
+	"tera/deployment/internal/domain/models"
+)
+
+type Reconciler interface {
+	LeaderDuty
+
+	Plan(background context.Context) (*models.Plan, error)
+	Reconcile(background context.Context) (*models.Plan, error)
+}
@@ -0,0 +1,14 @@
+package usecases
+
+import (
+	"context"
+	"tera/deployment/internal/domain/models"
+)
+
+type Scheduler interface {
+	LeaderDuty
+
+	Schedule(background context.Context, command models.KafkaMessage) (*models.Schedule, error)
+	Cancel(id string, requester models.Identity) (*models.Schedule, error)
+	List(state string) ([]models.Schedule, error)
+}
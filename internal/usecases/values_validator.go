@@ -0,0 +1,5 @@
+package usecases
+
+type ValuesValidator interface {
+	Validate(service, version string, values map[string]string) error
+}
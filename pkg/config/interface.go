@@ -1,17 +1,48 @@
 package config
 
+import "time"
+
 type Config struct {
-	Profile  string          `json:"profile"`
-	Services []ServiceConfig `yaml:"services"`
-	Argocd   ArgocdConfig    `yaml:"argocd"`
-	Kafka    KafkaConfig     `yaml:"kafka"`
-	Logging  LoggingConfig   `yaml:"logging"`
+	Profile        string               `json:"profile"`
+	Services       []ServiceConfig      `yaml:"services"`
+	Clusters       []ClusterConfig      `yaml:"clusters"`
+	Argocd         ArgocdConfig         `yaml:"argocd"`
+	Kafka          KafkaConfig          `yaml:"kafka"`
+	HTTP           HTTPConfig           `yaml:"http"`
+	GRPC           GRPCConfig           `yaml:"grpc"`
+	Storage        StorageConfig        `yaml:"storage"`
+	Jobs           JobsConfig           `yaml:"jobs"`
+	Operations     OperationsConfig     `yaml:"operations"`
+	Processor      ProcessorConfig      `yaml:"processor"`
+	Leader         LeaderConfig         `yaml:"leader"`
+	Reconciler     ReconcilerConfig     `yaml:"reconciler"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	Health         HealthConfig         `yaml:"health"`
+	Audit          AuditConfig          `yaml:"audit"`
+	Authentication AuthenticationConfig `yaml:"authentication"`
+	Authorization  AuthorizationConfig  `yaml:"authorization"`
+	Admission      AdmissionConfig      `yaml:"admission"`
+	Approvals      ApprovalsConfig      `yaml:"approvals"`
+	Freeze         FreezeConfig         `yaml:"freeze"`
+	Logging        LoggingConfig        `yaml:"logging"`
 }
 
 type ServiceConfig struct {
-	Name    string                `yaml:"name"`
-	Version string                `yaml:"version"`
-	Depends []ServiceDependConfig `yaml:"depends"`
+	Name     string                `yaml:"name"`
+	Version  string                `yaml:"version"`
+	Depends  []ServiceDependConfig `yaml:"depends"`
+	Schema   ServiceSchemaConfig   `yaml:"schema"`
+	Defaults ServiceDefaultsConfig `yaml:"defaults"`
+	Clusters []string              `yaml:"clusters"`
+	Targets  []ServiceTargetConfig `yaml:"targets"`
+}
+
+type ServiceTargetConfig struct {
+	Instance  string            `yaml:"instance"`
+	Version   string            `yaml:"version"`
+	Namespace string            `yaml:"namespace"`
+	Cluster   string            `yaml:"cluster"`
+	Values    map[string]string `yaml:"values"`
 }
 
 type ServiceDependConfig struct {
@@ -19,10 +50,27 @@ type ServiceDependConfig struct {
 	Version string `yaml:"version"`
 }
 
+type ServiceSchemaConfig struct {
+	Inline map[string]any `yaml:"inline"`
+	File   string         `yaml:"file"`
+	Chart  bool           `yaml:"chart"`
+}
+
+type ServiceDefaultsConfig struct {
+	Strict bool              `yaml:"strict"`
+	Values map[string]string `yaml:"values"`
+}
+
+type ClusterConfig struct {
+	Name   string `yaml:"name"`
+	Server string `yaml:"server"`
+}
+
 type ArgocdConfig struct {
 	URL        string               `yaml:"url"`
 	Token      string               `yaml:"token"`
 	Repository string               `yaml:"repository"`
+	Project    string               `yaml:"project"`
 	Metadata   ArgocdMetadataConfig `yaml:"metadata"`
 }
 
@@ -35,6 +83,24 @@ type KafkaConfig struct {
 	Protocol         string                       `yaml:"protocol"`
 	Sasl             KafkaSaslConfig              `yaml:"sasl"`
 	Topic            string                       `yaml:"topic"`
+	SecurityTopic    string                       `yaml:"security_topic"`
+	Signing          KafkaSigningConfig           `yaml:"signing"`
+}
+
+type KafkaSigningConfig struct {
+	Required bool                    `yaml:"required"`
+	MaxSkew  time.Duration           `yaml:"max_skew"`
+	Keys     []KafkaSigningKeyConfig `yaml:"keys"`
+}
+
+type KafkaSigningKeyConfig struct {
+	ID        string   `yaml:"id"`
+	Algorithm string   `yaml:"algorithm"`
+	Secret    string   `yaml:"secret"`
+	PublicKey string   `yaml:"public_key"`
+	Users     []string `yaml:"users"`
+	Teams     []string `yaml:"teams"`
+	System    string   `yaml:"system"`
 }
 
 type KafkaSaslConfig struct {
@@ -48,6 +114,131 @@ type KafkaBootstrapServerConfig struct {
 	Port int    `yaml:"port"`
 }
 
+type HTTPConfig struct {
+	Address        string   `yaml:"address"`
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type GRPCConfig struct {
+	Address string `yaml:"address"`
+}
+
+type StorageConfig struct {
+	Path      string        `yaml:"path"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+type JobsConfig struct {
+	SyncTimeout time.Duration `yaml:"sync_timeout"`
+}
+
+type OperationsConfig struct {
+	Busy         string        `yaml:"busy"`
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+}
+
+type ProcessorConfig struct {
+	Concurrency int `yaml:"concurrency"`
+	MaxPending  int `yaml:"max_pending"`
+}
+
+type LeaderConfig struct {
+	Mode        string `yaml:"mode"`
+	Identity    string `yaml:"identity"`
+	Name        string `yaml:"name"`
+	Namespace   string `yaml:"namespace"`
+	Path        string `yaml:"path"`
+	HTTPURL     string `yaml:"http_url"`
+	GRPCAddress string `yaml:"grpc_address"`
+}
+
+type ReconcilerConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	PlanOnly bool          `yaml:"plan_only"`
+	Prune    bool          `yaml:"prune"`
+	Interval time.Duration `yaml:"interval"`
+	File     string        `yaml:"file"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	Timeout    time.Duration `yaml:"timeout"`
+	Saturation float64       `yaml:"saturation"`
+}
+
+type AuditConfig struct {
+	Path string `yaml:"path"`
+	Key  string `yaml:"key"`
+}
+
+type AuthenticationConfig struct {
+	Tokens []AuthenticationTokenConfig `yaml:"tokens"`
+}
+
+type AuthenticationTokenConfig struct {
+	Token string `yaml:"token"`
+	User  string `yaml:"user"`
+	Team  string `yaml:"team"`
+}
+
+type AuthorizationConfig struct {
+	Enabled bool                      `yaml:"enabled"`
+	Rules   []AuthorizationRuleConfig `yaml:"rules"`
+}
+
+type AuthorizationRuleConfig struct {
+	Name       string   `yaml:"name"`
+	Users      []string `yaml:"users"`
+	Teams      []string `yaml:"teams"`
+	Systems    []string `yaml:"systems"`
+	Actions    []string `yaml:"actions"`
+	Services   []string `yaml:"services"`
+	Namespaces []string `yaml:"namespaces"`
+	Clusters   []string `yaml:"clusters"`
+}
+
+type AdmissionConfig struct {
+	Policies []AdmissionPolicyConfig `yaml:"policies"`
+}
+
+type AdmissionPolicyConfig struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+	Message    string `yaml:"message"`
+}
+
+type ApprovalsConfig struct {
+	Namespaces []string      `yaml:"namespaces"`
+	Required   int           `yaml:"required"`
+	Expiry     time.Duration `yaml:"expiry"`
+}
+
+type FreezeConfig struct {
+	Mode        string               `yaml:"mode"`
+	SyncWindows bool                 `yaml:"sync_windows"`
+	Windows     []FreezeWindowConfig `yaml:"windows"`
+}
+
+type FreezeWindowConfig struct {
+	Name       string        `yaml:"name"`
+	Schedule   string        `yaml:"schedule"`
+	Duration   time.Duration `yaml:"duration"`
+	Timezone   string        `yaml:"timezone"`
+	Mode       string        `yaml:"mode"`
+	Message    string        `yaml:"message"`
+	Services   []string      `yaml:"services"`
+	Namespaces []string      `yaml:"namespaces"`
+	Clusters   []string      `yaml:"clusters"`
+}
+
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 }
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const namespace = "tera"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	KafkaConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_consumed_total",
+		Help:      "Kafka messages consumed by action.",
+	}, []string{"action"})
+
+	KafkaProduced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_produced_total",
+		Help:      "Kafka messages produced by key.",
+	}, []string{"key"})
+
+	KafkaFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_failed_total",
+		Help:      "Kafka messages that could not be consumed or produced, by direction and key or action.",
+	}, []string{"direction", "name"})
+
+	CommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_duration_seconds",
+		Help:      "Time spent processing a command, by action and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"action", "result"})
+
+	ArgocdDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "argocd_request_duration_seconds",
+		Help:      "Argo CD API latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	ArgocdErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "argocd_request_errors_total",
+		Help:      "Failed Argo CD API calls by method.",
+	}, []string{"method"})
+
+	DependencyCheckFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dependency_check_failures_total",
+		Help:      "Deployments rejected because of missing dependencies, by service.",
+	}, []string{"service"})
+
+	TimeToHealthy = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "time_to_healthy_seconds",
+		Help:      "Time from accepting an operation until the application is Synced and Healthy, by service.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 8),
+	}, []string{"service"})
+
+	FreezeOverrides = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "freeze_overrides_total",
+		Help:      "State-changing actions that overrode an active freeze window, by window.",
+	}, []string{"window"})
+
+	SyncTrackers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sync_trackers_in_flight",
+		Help:      "Jobs currently waiting for their application to sync.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		KafkaConsumed,
+		KafkaProduced,
+		KafkaFailed,
+		CommandDuration,
+		ArgocdDuration,
+		ArgocdErrors,
+		DependencyCheckFailures,
+		TimeToHealthy,
+		FreezeOverrides,
+		SyncTrackers,
+	)
+}
+
+func EventsDepth(depth func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "events_channel_depth",
+		Help:      "Messages waiting in the internal events channel.",
+	}, func() float64 {
+		return float64(depth())
+	}))
+}
+
+func ObserveArgocd(method string, started time.Time, err error) {
+	ArgocdDuration.WithLabelValues(method).Observe(time.Since(started).Seconds())
+	if err != nil {
+		ArgocdErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strings"
+	"tera/deployment/pkg/config"
+	"tera/deployment/pkg/logger"
+)
+
+const (
+	instrumentation    = "tera/deployment"
+	defaultServiceName = "tera-deployment-server"
+)
+
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+func Init(conf *config.Config) *Provider {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if strings.ToLower(conf.Tracing.Exporter) != "otlp" {
+		return &Provider{}
+	}
+
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(conf.Tracing.Endpoint),
+	}
+	if conf.Tracing.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(context.Background(), options...)
+	if err != nil {
+		logger.Error("failed to create OTLP trace exporter", zap.Error(err))
+
+		panic(err)
+	}
+
+	serviceName := conf.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	sampleRatio := conf.Tracing.SampleRatio
+	if sampleRatio <= 0 {
+		sampleRatio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.DeploymentEnvironment(conf.Profile),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return &Provider{
+		provider: provider,
+	}
+}
+
+func (ctx *Provider) Shutdown(background context.Context) error {
+	if ctx.provider == nil {
+		return nil
+	}
+
+	return ctx.provider.Shutdown(background)
+}
+
+func Start(
+	background context.Context,
+	name string,
+	kind trace.SpanKind,
+	attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(
+		background,
+		name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}